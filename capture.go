@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// captureKeepSelector 截取文稿时保留的结构：列表、表格和脚注
+const captureKeepSelector = "ol, ul, table, sup, [class*=note], [id*=note]"
+
+// trimArticleHtml 截取文稿片段：保留开头 head 个顶层元素，以及其余包含列表、表格或脚注的顶层元素
+func trimArticleHtml(content string, head int) (string, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	doc.Find("body").Children().Each(func(i int, s *goquery.Selection) {
+		if i >= head && !s.Is(captureKeepSelector) && s.Find(captureKeepSelector).Length() == 0 {
+			return
+		}
+		if html, err := goquery.OuterHtml(s); err == nil {
+			sb.WriteString(html)
+			sb.WriteString("\n")
+		}
+	})
+	return sb.String(), nil
+}
+
+// runCaptureCommand 将接口返回的书籍文稿保存为 Html2Md 的测试用例：
+// fs capture -book 123 [-fragment 456] [-head 3] [-out utils/testdata/html2md]
+// 保存后使用 go test ./utils -run TestHtml2Md -update 生成期望结果，检查无误后提交
+func runCaptureCommand(args []string) {
+	flags := flag.NewFlagSet("capture", flag.ExitOnError)
+	bookID := flags.Int("book", 0, "书籍 id")
+	fragmentID := flags.Int("fragment", 0, "模块的 fragmentId，为 0 时使用解读文稿")
+	head := flags.Int("head", 3, "保留开头的顶层元素数量，其余只保留包含列表、表格或脚注的元素，小于 0 时保存完整文稿")
+	out := flags.String("out", filepath.Join("utils", "testdata", "html2md"), "保存目录")
+	name := flags.String("name", "", "文件名，不含扩展名，默认为 api-书籍id-fragmentId")
+	flags.Parse(args) // nolint
+
+	if err := captureArticle(*bookID, *fragmentID, *head, *out, *name); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+func captureArticle(bookID, fragmentID, head int, out, name string) error {
+	if bookID <= 0 {
+		return fmt.Errorf("请通过 -book 指定书籍 id")
+	}
+	if fragmentID == 0 {
+		detail, err := Instance.BookContent(bookID)
+		if err != nil {
+			return err
+		}
+		for _, article := range detail.Articles {
+			if article.ModuleCode == "articles" {
+				fragmentID = article.FragmentId
+			}
+		}
+		if fragmentID == 0 {
+			return fmt.Errorf("书籍 %d 没有解读文稿", bookID)
+		}
+	}
+
+	module, err := Instance.BookModuleContent(bookID, fragmentID)
+	if err != nil {
+		return err
+	}
+	content := module.Content
+	if strings.TrimSpace(content) == "" {
+		return fmt.Errorf("书籍 %d 的模块 %d 没有文字内容", bookID, fragmentID)
+	}
+	if head >= 0 {
+		if content, err = trimArticleHtml(content, head); err != nil {
+			return err
+		}
+	}
+
+	if err = os.MkdirAll(out, os.ModePerm); err != nil {
+		return err
+	}
+	if name == "" {
+		name = fmt.Sprintf("api-%d-%d", bookID, fragmentID)
+	}
+	fileName := filepath.Join(out, utils.FileName(name, "html"))
+	if err = utils.WriteFileWithTrunc(fileName, content); err != nil {
+		return err
+	}
+	fmt.Printf("已保存：【\033[37;1m%s\033[0m】，使用 go test ./utils -run TestHtml2Md -update 生成期望结果\n", fileName)
+	return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// commentPageSize 获取评论时每页的数量
+const commentPageSize = 20
+
+// defaultCommentCsvCount 导出评论 CSV 时未指定数量的默认条数
+const defaultCommentCsvCount = 200
+
+// commentDownloadTypes 受评论选项影响的下载类型：文稿、PDF、档案和评论 CSV
+var commentDownloadTypes = map[int]bool{3: true, 4: true, 10: true, 11: true, 12: true}
+
+// commentTabs 评论排序方式的名称
+var commentTabs = map[string]int{
+	"recommend": services.CommentTabRecommend,
+	"hot":       services.CommentTabHot,
+	"latest":    services.CommentTabLatest,
+}
+
+// parseCommentTab 解析评论排序方式，支持 recommend、hot、latest 或 1、2、3
+func parseCommentTab(tab string) (int, error) {
+	if tab == "" {
+		return services.CommentTabRecommend, nil
+	}
+	if tabType, ok := commentTabs[strings.ToLower(tab)]; ok {
+		return tabType, nil
+	}
+	tabType, err := strconv.Atoi(tab)
+	if err != nil || tabType < services.CommentTabRecommend || tabType > services.CommentTabLatest {
+		return 0, fmt.Errorf("不支持的评论排序方式: %s", tab)
+	}
+	return tabType, nil
+}
+
+// fetchComments 按排序方式获取书籍的前 n 条评论
+func fetchComments(detail services.BookContent, tabType, n int) (comments []services.Comment, err error) {
+	for pageNo := 1; len(comments) < n; pageNo++ {
+		list, err := Instance.BookComments(services.NewCommentParam(detail, tabType, pageNo, commentPageSize))
+		if err != nil {
+			return comments, err
+		}
+		comments = append(comments, list.CommentList...)
+		pageCount, _ := strconv.Atoi(list.Page.PageCount)
+		if len(list.CommentList) < commentPageSize || (pageCount > 0 && pageNo >= pageCount) {
+			break
+		}
+	}
+	if len(comments) > n {
+		comments = comments[:n]
+	}
+	return
+}
+
+// commentTime 评论时间，接口未返回格式化的时间时使用创建时间
+func commentTime(comment services.Comment) string {
+	if comment.CommentTime != "" {
+		return comment.CommentTime
+	}
+	if comment.CreateTime > 0 {
+		return utils.UnixMilli2String(comment.CreateTime)
+	}
+	return ""
+}
+
+// commentsHtml 评论列表，追加到文稿末尾
+func commentsHtml(comments []services.Comment) string {
+	if len(comments) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("<h2>精选评论</h2>")
+	for _, comment := range comments {
+		sb.WriteString("<blockquote>")
+		sb.WriteString(dossierParagraphs(comment.CommentContent))
+		meta := []string{comment.CommentUserName}
+		if comment.LikeCount != "" && comment.LikeCount != "0" {
+			meta = append(meta, comment.LikeCount+" 赞")
+		}
+		if t := commentTime(comment); t != "" {
+			meta = append(meta, t)
+		}
+		sb.WriteString(`<p class="meta">— ` + html.EscapeString(strings.Join(meta, " · ")) + "</p>")
+		sb.WriteString("</blockquote>")
+	}
+	return sb.String()
+}
+
+// appendComments 下载选项中指定了评论数量时，在内容末尾追加评论；获取失败时只提示，不影响文稿生成
+func appendComments(content string, detail services.BookContent, opt DownloadOptions) string {
+	if opt.Comments <= 0 {
+		return content
+	}
+	comments, err := fetchComments(detail, opt.CommentTab, opt.Comments)
+	if err != nil {
+		fmt.Printf("【\033[31;1m%s\033[0m】获取评论失败: %v\n", strings.TrimSpace(detail.BookInfo.Title), err)
+	}
+	return content + commentsHtml(comments)
+}
+
+// genCommentsCsv 导出书籍评论为 CSV，便于分析
+func genCommentsCsv(fileName string, detail services.BookContent, opt DownloadOptions) error {
+	n := opt.Comments
+	if n <= 0 {
+		n = defaultCommentCsvCount
+	}
+	comments, err := fetchComments(detail, opt.CommentTab, n)
+	if err != nil {
+		if len(comments) == 0 {
+			return err
+		}
+		// 已获取的评论仍然导出，提示评论不完整
+		fmt.Printf("【\033[31;1m%s\033[0m】获取评论失败，只导出已获取的 %d 条: %v\n", strings.TrimSpace(detail.BookInfo.Title), len(comments), err)
+	}
+
+	var sb strings.Builder
+	// 写入 BOM，Excel 打开时不乱码
+	sb.WriteString("\ufeff")
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"评论ID", "用户", "内容", "点赞数", "回复数", "时间", "IP属地", "讲者互动", "话题"})
+	for _, comment := range comments {
+		_ = w.Write([]string{
+			utils.Int2String(comment.CommentId),
+			comment.CommentUserName,
+			comment.CommentContent,
+			comment.LikeCount,
+			comment.RepliedTotalCount,
+			commentTime(comment),
+			comment.IpAddress,
+			utils.Bool2String(comment.HasSpeaker),
+			comment.TopicTitle,
+		})
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	return utils.SaveFile(fileName, sb.String())
+}
+
+// handleGetBookComments 书籍评论列表，tabType 为 recommend、hot、latest，支持 pageNo、pageSize 分页
+func handleGetBookComments(c *gin.Context) {
+	bookId, _ := strconv.Atoi(c.Param("id"))
+	tabType, err := parseCommentTab(c.Query("tabType"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	pageNo, _ := strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", utils.Int2String(commentPageSize)))
+	if pageNo < 1 || pageSize < 1 || pageSize > 100 {
+		Error(c, fmt.Errorf("分页参数无效"))
+		return
+	}
+
+	detail, err := Instance.BookContent(bookId)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	list, err := Instance.BookComments(services.NewCommentParam(detail, tabType, pageNo, pageSize))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, list)
+}
@@ -14,11 +14,48 @@ var Conf Config
 var Viper *viper.Viper
 
 type Config struct {
-	AesKey      string
-	AppID       string
-	Token       string
-	Wkhtmltopdf string
-	Ffmpeg      string
+	AesKey           string
+	AppID            string
+	Token            string
+	Wkhtmltopdf      string
+	Ffmpeg           string
+	Ffprobe          string
+	LoudnessTarget   float64               // 响度标准化目标值(LUFS)，0 表示不处理
+	LoudnessMode     string                // 响度标准化方式: gain-调整音量, tag-写入ReplayGain标签
+	CoverMaxSize     int                   // 封面最长边像素，超过时等比缩小，0 表示不缩放
+	PdfFont          string                // PDF 正文使用的本地中文字体文件，内置 PDF 引擎要求为 TrueType(.ttf) 字体
+	PdfFonts         map[string]string     // PDF 文稿中字体族名对应的本地字体文件
+	PdfFontBold      string                // 内置 PDF 引擎使用的粗体中文字体文件，为空时粗体以强调色显示
+	PdfEngine        string                // PDF 引擎: auto、wkhtmltopdf、builtin
+	PdfProfile       string                // 默认 PDF 版式名称
+	PdfProfiles      map[string]PdfProfile // 自定义 PDF 版式，与内置版式同名时覆盖内置版式
+	MdLocalImages    bool                  // Markdown 文稿中的图片下载到 .md 同级的 assets 目录
+	MindmapBundle    string                // 思维导图合并方式: png-拼接为长图, pdf-每张图一页，为空时不合并
+	GiftCardWarnDays int                   // 礼品卡到期提醒天数，0 使用默认值 7 天，小于 0 时不提醒
+}
+
+// PdfProfile PDF 版式，边距单位为毫米
+type PdfProfile struct {
+	PageSize     string  `json:"pageSize"`     // 纸张大小：A4、A5、B6、Letter 等
+	PageWidth    string  `json:"pageWidth"`    // 自定义页面宽度，需带单位，如 90mm、3.6in，设置后忽略 PageSize
+	PageHeight   string  `json:"pageHeight"`   // 自定义页面高度
+	Orientation  string  `json:"orientation"`  // 方向：Portrait 或 Landscape
+	MarginTop    uint    `json:"marginTop"`    // 上边距，0 使用默认值
+	MarginBottom uint    `json:"marginBottom"` // 下边距，0 使用默认值
+	MarginLeft   uint    `json:"marginLeft"`   // 左边距，0 使用默认值
+	MarginRight  uint    `json:"marginRight"`  // 右边距，0 使用默认值
+	FontSize     float64 `json:"fontSize"`     // 正文字号(pt)，0 使用默认字号
+	Dpi          uint    `json:"dpi"`          // 0 使用默认值
+	Toc          bool    `json:"toc"`          // 生成目录
+	Cover        bool    `json:"cover"`        // 生成封面页
+	// 页眉页脚模板，{title} 替换为书名，{speaker} 替换为讲者，
+	// 支持 wkhtmltopdf 的 [page]、[topage]、[section] 等变量
+	HeaderLeft   string `json:"headerLeft"`
+	HeaderCenter string `json:"headerCenter"`
+	HeaderRight  string `json:"headerRight"`
+	FooterLeft   string `json:"footerLeft"`
+	FooterCenter string `json:"footerCenter"`
+	FooterRight  string `json:"footerRight"`
 }
 
 func init() {
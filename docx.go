@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// genDocx 将文稿 HTML 生成 Word 文档，标题使用“标题”样式
+func genDocx(fileName, title, author, content string) error {
+	doc := utils.NewDocx(title)
+	doc.Creator = author
+	doc.AddTitle(title)
+	if err := doc.AddHtml(articleHtml(content)); err != nil {
+		return err
+	}
+	return doc.Write(fileName)
+}
+
+// genProgramDocx 将课程节目的文稿生成 Word 文档
+func genProgramDocx(fileName string, courseID int, program services.Program, author string) error {
+	programDetail, err := Instance.ProgramDetail(services.ProgramDetailParam{
+		AlbumId:    courseID,
+		ProgramId:  program.Id,
+		FragmentId: program.FragmentId,
+	})
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(programDetail.Content) == "" {
+		return fmt.Errorf("无文稿")
+	}
+	return genDocx(fileName, strings.TrimSpace(program.Title), author, programDetail.Content)
+}
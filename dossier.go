@@ -0,0 +1,185 @@
+package main
+
+import (
+	"html"
+	"strings"
+
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// dossierSection 档案中的一节，内容为空时不输出
+func dossierSection(sb *strings.Builder, title, content string) {
+	if content == "" {
+		return
+	}
+	sb.WriteString("<h2>" + html.EscapeString(title) + "</h2>")
+	sb.WriteString(content)
+}
+
+// dossierParagraphs 多行文字按段落输出
+func dossierParagraphs(text string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sb.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	return sb.String()
+}
+
+// dossierMeta 书籍基本信息
+func dossierMeta(detail services.BookContent, businessType int) []string {
+	info := detail.BookInfo
+	var authors []string
+	for _, author := range detail.Authors {
+		if author.Name != "" {
+			authors = append(authors, author.Name)
+		}
+	}
+	meta := []string{"讲者：" + info.SpeakerName}
+	if len(authors) > 0 {
+		meta = append(meta, "作者："+strings.Join(authors, "、"))
+	}
+	meta = append(meta, "栏目："+getSubDir(businessType))
+	if info.Score != "" {
+		meta = append(meta, "评分："+info.Score)
+	}
+	if info.PublishTime > 0 {
+		meta = append(meta, "上架时间："+utils.UnixMilli2DateString(info.PublishTime))
+	}
+	if info.PlayCount > 0 {
+		meta = append(meta, "播放次数："+utils.Int2String(info.PlayCount))
+	}
+	return meta
+}
+
+// dossierHtml 书籍档案正文：简介、要点、金句、作者和讲者简介、讨论话题、制作团队、排行和推荐
+func dossierHtml(detail services.BookContent) string {
+	var sb strings.Builder
+	info := detail.BookInfo
+
+	dossierSection(&sb, "简介", dossierParagraphs(info.Summary)+dossierParagraphs(detail.BusinessIntroduce))
+
+	if len(detail.Acquire.Intros) > 0 {
+		title := detail.Acquire.Title
+		if title == "" {
+			title = "你将获得"
+		}
+		var list strings.Builder
+		list.WriteString("<ol>")
+		for _, intro := range detail.Acquire.Intros {
+			list.WriteString("<li>" + html.EscapeString(intro) + "</li>")
+		}
+		list.WriteString("</ol>")
+		dossierSection(&sb, title, list.String())
+	}
+
+	var quotes strings.Builder
+	for _, quote := range detail.Extract.Infos {
+		if text := dossierParagraphs(quote.Intro); text != "" {
+			quotes.WriteString("<blockquote>" + text + "</blockquote>")
+		}
+	}
+	dossierSection(&sb, "金句", quotes.String())
+
+	var authors strings.Builder
+	for _, author := range detail.Authors {
+		if author.Name == "" && author.Summary == "" {
+			continue
+		}
+		authors.WriteString("<h3>" + html.EscapeString(author.Name) + "</h3>")
+		if len(author.Tags) > 0 {
+			authors.WriteString(`<p class="meta">` + html.EscapeString(strings.Join(author.Tags, " / ")) + "</p>")
+		}
+		authors.WriteString(dossierParagraphs(author.Summary))
+	}
+	dossierSection(&sb, "作者简介", authors.String())
+
+	var speakers strings.Builder
+	for _, speaker := range detail.Speakers {
+		if speaker.Name == "" && speaker.Summary == "" {
+			continue
+		}
+		speakers.WriteString("<h3>" + html.EscapeString(speaker.Name) + "</h3>")
+		if speaker.BookNum > 0 {
+			speakers.WriteString(`<p class="meta">已解读 ` + utils.Int2String(speaker.BookNum) + " 本书</p>")
+		}
+		speakers.WriteString(dossierParagraphs(speaker.Summary))
+	}
+	dossierSection(&sb, "讲者简介", speakers.String())
+
+	dossierSection(&sb, "讨论话题", dossierParagraphs(detail.TopicVO.TopicContent))
+
+	if len(detail.PolishFlow.Persons) > 0 {
+		title := detail.PolishFlow.Title
+		if title == "" {
+			title = "制作团队"
+		}
+		var persons strings.Builder
+		persons.WriteString("<ul>")
+		for _, person := range detail.PolishFlow.Persons {
+			persons.WriteString("<li><strong>" + html.EscapeString(person.Flow) + "</strong>：" + html.EscapeString(person.Name) + "</li>")
+		}
+		persons.WriteString("</ul>")
+		dossierSection(&sb, title, persons.String())
+	}
+
+	if rank := detail.RankVO; rank.RankName != "" {
+		text := rank.RankName
+		if rank.Ranking > 0 {
+			text += " 第 " + utils.Int2String(rank.Ranking) + " 名"
+		}
+		dossierSection(&sb, "排行", "<p>"+html.EscapeString(text)+"</p>"+dossierParagraphs(rank.RankDesc))
+	}
+
+	var recommend strings.Builder
+	if vo := detail.RecommendVO; vo.RecommendName != "" || vo.RecommendInfo != "" {
+		if vo.RecommendName != "" {
+			recommend.WriteString("<h3>" + html.EscapeString(vo.RecommendName) + "</h3>")
+		}
+		recommend.WriteString(dossierParagraphs(vo.RecommendInfo))
+	}
+	if len(detail.RecommendBook) > 0 {
+		recommend.WriteString("<ul>")
+		for _, book := range detail.RecommendBook {
+			item := "《" + strings.TrimSpace(book.Title) + "》"
+			if book.SpeakerName != "" {
+				item += " — " + book.SpeakerName
+			}
+			recommend.WriteString("<li>" + html.EscapeString(item))
+			if book.Summary != "" {
+				recommend.WriteString("：" + html.EscapeString(book.Summary))
+			}
+			recommend.WriteString("</li>")
+		}
+		recommend.WriteString("</ul>")
+	}
+	dossierSection(&sb, "相关推荐", recommend.String())
+
+	return sb.String()
+}
+
+// genDossierMd 生成 Markdown 格式的书籍档案
+func genDossierMd(fileName string, detail services.BookContent, businessType int, opt DownloadOptions) error {
+	var sb strings.Builder
+	sb.WriteString("<h1>" + html.EscapeString(strings.TrimSpace(detail.BookInfo.Title)) + "</h1><ul>")
+	for _, item := range dossierMeta(detail, businessType) {
+		sb.WriteString("<li>" + html.EscapeString(item) + "</li>")
+	}
+	sb.WriteString("</ul>")
+	sb.WriteString(appendComments(dossierHtml(detail), detail, opt))
+	return utils.SaveFile(fileName, utils.Html2Md(sb.String()))
+}
+
+// genDossierHtml 生成离线 HTML 格式的书籍档案
+func genDossierHtml(fileName string, detail services.BookContent, businessType int, opt DownloadOptions) error {
+	info := detail.BookInfo
+	title := strings.TrimSpace(info.Title)
+	page := utils.OfflineHtml{
+		Title:   title + " - 书籍档案",
+		Header:  offlineHeaderHtml(info.CoverImg, title, dossierMeta(detail, businessType), "", ""),
+		Content: appendComments(dossierHtml(detail), detail, opt),
+	}
+	return page.Write(fileName)
+}
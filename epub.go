@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// articleHtml 提取文稿正文 HTML，没有 rich_media_content 容器时返回原内容
+func articleHtml(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	if s := doc.Find("div.rich_media_content").First(); s.Length() > 0 {
+		res, _ := s.Html()
+		return res
+	}
+	res, _ := doc.Find("body").Html()
+	return res
+}
+
+// bookIntroHtml 书籍简介：摘要、你将获得、作者简介
+func bookIntroHtml(detail services.BookContent) string {
+	var sb strings.Builder
+	info := detail.BookInfo
+	sb.WriteString(fmt.Sprintf(`<p class="meta">讲者：%s　评分：%s</p>`, html.EscapeString(info.SpeakerName), html.EscapeString(info.Score)))
+	if info.Summary != "" {
+		sb.WriteString("<p>" + html.EscapeString(info.Summary) + "</p>")
+	}
+	if len(detail.Acquire.Intros) > 0 {
+		title := detail.Acquire.Title
+		if title == "" {
+			title = "你将获得"
+		}
+		sb.WriteString("<h2>" + html.EscapeString(title) + "</h2><ul>")
+		for _, intro := range detail.Acquire.Intros {
+			sb.WriteString("<li>" + html.EscapeString(intro) + "</li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	for _, author := range detail.Authors {
+		if author.Summary == "" {
+			continue
+		}
+		sb.WriteString("<h2>作者：" + html.EscapeString(author.Name) + "</h2>")
+		sb.WriteString("<p>" + html.EscapeString(author.Summary) + "</p>")
+	}
+	return sb.String()
+}
+
+// genBookEpub 生成书籍 EPUB：封面、简介、文稿，目录根据文稿中的标题生成
+func genBookEpub(fileName string, detail services.BookContent, content string) error {
+	info := detail.BookInfo
+	book := utils.NewEpub(strings.TrimSpace(info.Title))
+	book.Identifier = "urn:fs-gui:book:" + utils.Int2String(info.BookId)
+	book.Description = info.Summary
+	book.Publisher = getSubDir(info.BusinessType)
+	if info.PublishTime > 0 {
+		book.Date = utils.UnixMilli2DateString(info.PublishTime)
+	}
+	for _, author := range detail.Authors {
+		book.Authors = append(book.Authors, author.Name)
+		book.Subjects = append(book.Subjects, author.Tags...)
+	}
+	if info.SpeakerName != "" {
+		book.Contributors = append(book.Contributors, info.SpeakerName)
+	}
+
+	if cover, _ := fetchCover(info.CoverImg); len(cover) > 0 {
+		if err := book.SetCover(cover); err != nil {
+			return err
+		}
+	}
+
+	if _, err := book.AddChapter("简介", bookIntroHtml(detail)); err != nil {
+		return err
+	}
+	if _, err := book.AddChapter("文稿", articleHtml(content)); err != nil {
+		return err
+	}
+	return book.Write(fileName)
+}
+
+// courseEpubCacheDir 课程目录下缓存节目文稿的目录，新节目发布后只需获取新增的文稿
+const courseEpubCacheDir = ".epub"
+
+// textHtml 将接口返回的纯文本按行转换为段落，已经是 HTML 的内容原样返回
+func textHtml(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "<") {
+		return text
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			sb.WriteString("<p>" + html.EscapeString(line) + "</p>")
+		}
+	}
+	return sb.String()
+}
+
+// courseIntroHtml 课程简介：副标题、适合人群、讲者介绍
+func courseIntroHtml(detail services.CourseInfo) string {
+	var sb strings.Builder
+	if detail.SubTitle != "" {
+		sb.WriteString(`<p class="meta">` + html.EscapeString(detail.SubTitle) + "</p>")
+	}
+	if detail.Author != "" {
+		sb.WriteString(`<p class="meta">讲者：` + html.EscapeString(detail.Author) + "</p>")
+	}
+	if detail.SuitedPeople != "" {
+		sb.WriteString("<h2>适合人群</h2>" + textHtml(detail.SuitedPeople))
+	}
+	if detail.AuthorIntro != "" {
+		sb.WriteString("<h2>讲者介绍</h2>" + textHtml(detail.AuthorIntro))
+	}
+	return sb.String()
+}
+
+// programContent 获取节目文稿，优先读取缓存；文稿为空（如未解锁）时不缓存，下次重新获取
+func programContent(cacheDir string, courseID int, program services.Program) (content string, cached bool, err error) {
+	cacheFile := filepath.Join(cacheDir, utils.Int2String(program.Id)+".html")
+	if data, err := os.ReadFile(cacheFile); err == nil {
+		return string(data), true, nil
+	}
+	programDetail, err := Instance.ProgramDetail(services.ProgramDetailParam{
+		AlbumId:    courseID,
+		ProgramId:  program.Id,
+		FragmentId: program.FragmentId,
+	})
+	if err != nil {
+		return
+	}
+	content = programDetail.Content
+	if strings.TrimSpace(content) != "" {
+		err = utils.WriteFileWithTrunc(cacheFile, content)
+	}
+	return
+}
+
+// genCourseEpub 生成课程 EPUB：每个章节为一节，节目文稿为其下的子章节
+// 文稿缓存在课程目录中，没有获取到新文稿时跳过生成，有新节目时只获取新增的文稿
+func genCourseEpub(fileName string, courseID int, detail services.CourseInfo, list []services.Program, cover []byte) error {
+	courseIDStr := utils.Int2String(courseID)
+	cacheDir, err := utils.Mkdir(filepath.Dir(fileName), courseEpubCacheDir)
+	if err != nil {
+		return err
+	}
+
+	contents := make(map[int]string, len(list))
+	fetched := 0
+	for i, program := range list {
+		content, cached, err := programContent(cacheDir, courseID, program)
+		if err != nil {
+			fmt.Printf("【\033[31;1m%s\033[0m】获取文稿失败: %v\n", program.Title, err)
+			continue
+		}
+		if !cached && strings.TrimSpace(content) != "" {
+			fetched++
+		}
+		contents[program.Id] = content
+		SendDownloadProgress(courseIDStr, "course", detail.Title, i+1, len(list))
+	}
+	if fetched == 0 && utils.CheckFileExist(fileName) {
+		fmt.Printf("【\033[37;1m%s\033[0m】已是最新\n", fileName)
+		return nil
+	}
+
+	book := utils.NewEpub(strings.TrimSpace(detail.Title))
+	book.Identifier = "urn:fs-gui:course:" + courseIDStr
+	book.Description = detail.SubTitle
+	book.Publisher = getSubDir(4)
+	if detail.Author != "" {
+		book.Authors = append(book.Authors, detail.Author)
+	}
+	if detail.CategoryName != "" {
+		book.Subjects = append(book.Subjects, detail.CategoryName)
+	}
+	if len(cover) > 0 {
+		if err = book.SetCover(cover); err != nil {
+			return err
+		}
+	}
+	if _, err = book.AddChapter("课程简介", courseIntroHtml(detail)); err != nil {
+		return err
+	}
+
+	sections := make(map[int]*utils.EpubChapter)
+	for _, program := range list {
+		content, ok := contents[program.Id]
+		if !ok {
+			continue
+		}
+		var parent *utils.EpubChapter
+		if info := program.ChapterInfo; info != nil {
+			if parent, ok = sections[info.ChapterId]; !ok {
+				if parent, err = book.AddChapter(strings.TrimSpace(info.ChapterName), ""); err != nil {
+					return err
+				}
+				sections[info.ChapterId] = parent
+			}
+		}
+		if strings.TrimSpace(content) == "" {
+			content = "<p>暂无文稿</p>"
+		}
+		if _, err = book.AddSubChapter(parent, strings.TrimSpace(program.Title), articleHtml(content)); err != nil {
+			return err
+		}
+	}
+	return book.Write(fileName)
+}
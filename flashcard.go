@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// flashcardSubDir 闪卡保存目录
+const flashcardSubDir = "闪卡"
+
+// defaultDeckName 未指定卡组名称时使用的卡组，重复导出时合并到同一个文件
+const defaultDeckName = "读书会闪卡"
+
+// 闪卡格式
+const (
+	FlashcardFormatTsv  = "tsv"
+	FlashcardFormatCsv  = "csv"
+	FlashcardFormatJson = "json"
+)
+
+// Flashcard 一张闪卡，Guid 由书籍和卡片内容生成，重复导出时用于去重
+type Flashcard struct {
+	Guid    string   `json:"guid"`
+	BookId  int      `json:"bookId,omitempty"`
+	Kind    string   `json:"kind,omitempty"` // quote-金句 takeaway-要点
+	Front   string   `json:"front"`
+	Back    string   `json:"back"`
+	Book    string   `json:"book"`
+	Speaker string   `json:"speaker"`
+	Tags    []string `json:"tags"`
+}
+
+// FlashcardDeck 通用 JSON 卡组
+type FlashcardDeck struct {
+	Deck  string      `json:"deck"`
+	Cards []Flashcard `json:"cards"`
+}
+
+// flashcardText 卡片内容合并为一行
+func flashcardText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// bookFlashcards 由书籍的金句和要点生成闪卡：金句正面为原文，背面为出处；要点正面为书名和序号，背面为要点内容
+func bookFlashcards(detail services.BookContent, businessType int) (cards []Flashcard) {
+	info := detail.BookInfo
+	title := strings.TrimSpace(info.Title)
+	speaker := strings.TrimSpace(info.SpeakerName)
+
+	var tags []string
+	seen := map[string]bool{}
+	addTag := func(tag string) {
+		if tag = vaultTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	addTag(getSubDir(businessType))
+	addTag(title)
+	for _, author := range detail.Authors {
+		for _, tag := range author.Tags {
+			addTag(tag)
+		}
+	}
+
+	// key 为卡片的原始内容，要点的序号变化时 Guid 不变
+	newCard := func(kind, key, front, back string) Flashcard {
+		return Flashcard{
+			Guid:    utils.Md5(fmt.Sprintf("%d|%s|%s", info.BookId, kind, key)),
+			BookId:  info.BookId,
+			Kind:    kind,
+			Front:   front,
+			Back:    back,
+			Book:    title,
+			Speaker: speaker,
+			Tags:    append(append([]string{}, tags...), kind),
+		}
+	}
+
+	source := "《" + title + "》"
+	if speaker != "" {
+		source += " — " + speaker
+	}
+	for _, quote := range detail.Extract.Infos {
+		if text := flashcardText(quote.Intro); text != "" {
+			cards = append(cards, newCard("quote", text, text, source))
+		}
+	}
+
+	var intros []string
+	for _, intro := range detail.Acquire.Intros {
+		if text := flashcardText(intro); text != "" {
+			intros = append(intros, text)
+		}
+	}
+	for i, intro := range intros {
+		front := fmt.Sprintf("《%s》你将获得（%d/%d）", title, i+1, len(intros))
+		cards = append(cards, newCard("takeaway", intro, front, intro))
+	}
+	return
+}
+
+// readFlashcards 读取已导出的卡组，文件不存在时返回空
+func readFlashcards(fileName, format string) (cards []Flashcard, err error) {
+	if !utils.CheckFileExist(fileName) {
+		return
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	if format == FlashcardFormatJson {
+		var deck FlashcardDeck
+		err = utils.UnmarshalJSON(data, &deck)
+		return deck.Cards, err
+	}
+
+	r := csv.NewReader(strings.NewReader(string(data)))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if format == FlashcardFormatTsv {
+		r.Comma = '\t'
+	}
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if len(record) < 6 {
+			continue
+		}
+		cards = append(cards, Flashcard{
+			Guid:    record[0],
+			Front:   record[1],
+			Back:    record[2],
+			Book:    record[3],
+			Speaker: record[4],
+			Tags:    strings.Fields(record[5]),
+		})
+	}
+	return
+}
+
+// mergeFlashcards 按 Guid 合并卡片，已有卡片的位置不变，内容更新为最新导出的内容
+func mergeFlashcards(existing, cards []Flashcard) (merged []Flashcard, added int) {
+	index := make(map[string]int, len(existing))
+	for _, card := range existing {
+		if _, ok := index[card.Guid]; ok {
+			continue
+		}
+		index[card.Guid] = len(merged)
+		merged = append(merged, card)
+	}
+	for _, card := range cards {
+		if i, ok := index[card.Guid]; ok {
+			merged[i] = card
+			continue
+		}
+		index[card.Guid] = len(merged)
+		merged = append(merged, card)
+		added++
+	}
+	return
+}
+
+// writeFlashcards 保存卡组
+// TSV、CSV 使用 Anki 的文件头声明分隔符、卡组和 guid 列，再次导入时更新已有笔记而不是重复添加
+// 列依次为：guid、正面、背面、书名、讲者、标签
+func writeFlashcards(fileName, format, deck string, cards []Flashcard) error {
+	var content string
+	if format == FlashcardFormatJson {
+		data, err := utils.MarshalJSON(FlashcardDeck{Deck: deck, Cards: cards})
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	} else {
+		var sb strings.Builder
+		separator := "comma"
+		if format == FlashcardFormatTsv {
+			separator = "tab"
+		}
+		sb.WriteString("#separator:" + separator + "\n")
+		sb.WriteString("#html:false\n")
+		sb.WriteString("#notetype:Basic\n")
+		sb.WriteString("#deck:" + deck + "\n")
+		sb.WriteString("#guid column:1\n")
+		sb.WriteString("#tags column:6\n")
+		w := csv.NewWriter(&sb)
+		if format == FlashcardFormatTsv {
+			w.Comma = '\t'
+		}
+		for _, card := range cards {
+			if err := w.Write([]string{card.Guid, card.Front, card.Back, card.Book, card.Speaker, strings.Join(card.Tags, " ")}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		content = sb.String()
+	}
+
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err := utils.WriteFileWithTrunc(fileName, content); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
+
+// DownloadFlashcards 导出书籍的金句和要点闪卡，与同名卡组中已有的卡片合并
+func DownloadFlashcards(deck, format string, bookIDs []int, businessType int) (err error) {
+	id := "flashcards:" + deck
+	SendDownloadStarted(id, "flashcards", deck)
+	defer func() {
+		if err != nil {
+			SendDownloadFailed(id, "flashcards", deck, err.Error())
+		} else {
+			SendDownloadCompleted(id, "flashcards", deck)
+		}
+	}()
+
+	filePath, err := utils.Mkdir(OutputDir, flashcardSubDir)
+	if err != nil {
+		return
+	}
+	fileName := filepath.Join(filePath, utils.FileName(deck, format))
+
+	var cards []Flashcard
+	for i, bookID := range bookIDs {
+		detail, err := Instance.BookContent(bookID)
+		if err != nil {
+			fmt.Printf("【\033[31;1m%d\033[0m】获取书籍失败: %v\n", bookID, err)
+		} else {
+			bType := businessType
+			if bType == 0 {
+				bType = detail.BookInfo.BusinessType
+			}
+			cards = append(cards, bookFlashcards(detail, bType)...)
+		}
+		SendDownloadProgress(id, "flashcards", deck, i+1, len(bookIDs))
+	}
+	if len(cards) == 0 {
+		return fmt.Errorf("所选书籍没有金句或要点")
+	}
+
+	existing, err := readFlashcards(fileName, format)
+	if err != nil {
+		return fmt.Errorf("读取已有卡组失败: %v", err)
+	}
+	merged, added := mergeFlashcards(existing, cards)
+	fmt.Printf("卡组【\033[37;1m%s\033[0m】新增 %d 张，共 %d 张\n", deck, added, len(merged))
+	return writeFlashcards(fileName, format, deck, merged)
+}
+
+// handleDownloadFlashcards 导出闪卡：书籍的指定方式同合集，format 为 tsv、csv 或 json，deck 为卡组名称
+func handleDownloadFlashcards(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", FlashcardFormatTsv))
+	switch format {
+	case FlashcardFormatTsv, FlashcardFormatCsv, FlashcardFormatJson:
+	default:
+		Error(c, fmt.Errorf("不支持的闪卡格式: %s", format))
+		return
+	}
+
+	bookIDs, err := queryBookIDs(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	if len(bookIDs) == 0 {
+		Error(c, fmt.Errorf("请指定需要导出的书籍"))
+		return
+	}
+
+	deck := strings.TrimSpace(c.Query("deck"))
+	if deck == "" {
+		deck = defaultDeckName
+	}
+	businessType, _ := strconv.Atoi(c.Query("businessType"))
+	go DownloadFlashcards(deck, format, bookIDs, businessType)
+	Success(c, gin.H{"deck": deck, "format": format, "total": len(bookIDs)})
+}
@@ -181,6 +181,11 @@ export const api = {
     return handleApiResponse<Course[]>(res.data)
   },
 
+  async getCourseCategories(businessZone?: number): Promise<Category[]> {
+    const res = await apiClient.get('/api/courses/categories', { params: { businessZone } })
+    return handleApiResponse<Category[]>(res.data) || []
+  },
+
   async getCourseDetail(id: string): Promise<CourseDetail> {
     const res = await apiClient.get(`/api/courses/${id}`)
     return handleApiResponse<CourseDetail>(res.data)
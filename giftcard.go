@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/config"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// giftCardSubDir 礼品卡到期报告保存目录
+const giftCardSubDir = "礼品卡"
+
+// defaultGiftCardWarnDays 未配置提醒天数时，礼品卡在 7 天内到期发送提醒
+const defaultGiftCardWarnDays = 7
+
+// 启动后首次检查礼品卡到期的延迟和之后的检查间隔
+const (
+	giftCardCheckDelay    = 30 * time.Second
+	giftCardCheckInterval = time.Hour
+)
+
+// 礼品卡到期状态
+const (
+	GiftCardValid    = "valid"    // 未到期
+	GiftCardExpiring = "expiring" // 即将到期
+	GiftCardExpired  = "expired"  // 已过期
+	GiftCardNoExpiry = "none"     // 没有到期时间
+	GiftCardHandled  = "handled"  // 已赠送或已使用，不再提醒到期
+)
+
+// 礼品卡的赠送和使用状态，0 表示未赠送、未使用
+const (
+	giftCardUnsent = 0
+	giftCardUnused = 0
+)
+
+// giftCardPending 礼品卡是否仍待赠送：未赠送、未使用且不是平台代为发放的卡
+func giftCardPending(status, useStatus int, platformSended bool) bool {
+	return status == giftCardUnsent && useStatus == giftCardUnused && !platformSended
+}
+
+// GiftCardInfo 礼品卡及到期信息
+type GiftCardInfo struct {
+	services.GiftCard
+	ExpireTime   string `json:"expireTime"`
+	DaysLeft     int    `json:"daysLeft"`
+	ExpiryStatus string `json:"expiryStatus"`
+}
+
+// GiftCardDetailInfo 礼品卡详情及到期信息
+type GiftCardDetailInfo struct {
+	services.GiftCardDetail
+	ExpireTime   string `json:"expireTime"`
+	DaysLeft     int    `json:"daysLeft"`
+	ExpiryStatus string `json:"expiryStatus"`
+}
+
+// notifiedGiftCards 已发送过到期提醒的礼品卡，每张卡只提醒一次
+var (
+	notifiedGiftCards = make(map[int64]bool)
+	notifiedMutex     sync.Mutex
+)
+
+// giftCardWarnDays 到期提醒天数，小于 0 时不提醒
+func giftCardWarnDays() int {
+	if config.Conf.GiftCardWarnDays == 0 {
+		return defaultGiftCardWarnDays
+	}
+	return config.Conf.GiftCardWarnDays
+}
+
+// giftCardExpiry 计算到期时间、剩余天数和到期状态，剩余天数按自然日计算，当天到期为 0，已过期为 -1
+// 已赠送或已使用的卡状态为 handled，不再区分是否到期
+func giftCardExpiry(expireDate int64, pending bool, now time.Time) (expireTime string, daysLeft int, status string) {
+	expired := false
+	if expireDate > 0 {
+		expire := time.UnixMilli(expireDate)
+		expireTime = utils.UnixMilli2String(expireDate)
+		if expired = !expire.After(now); expired {
+			daysLeft = -1
+		} else {
+			today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+			day := time.Date(expire.Year(), expire.Month(), expire.Day(), 0, 0, 0, 0, now.Location())
+			daysLeft = int(day.Sub(today).Hours() / 24)
+		}
+	}
+
+	warnDays := giftCardWarnDays()
+	switch {
+	case !pending:
+		status = GiftCardHandled
+	case expireDate <= 0:
+		status = GiftCardNoExpiry
+	case expired:
+		status = GiftCardExpired
+	case warnDays >= 0 && daysLeft <= warnDays:
+		status = GiftCardExpiring
+	default:
+		status = GiftCardValid
+	}
+	return
+}
+
+// giftCardInfos 礼品卡列表附加到期信息，按到期时间排序，没有到期时间的排在最后
+func giftCardInfos(cards []services.GiftCard) []GiftCardInfo {
+	now := time.Now()
+	infos := make([]GiftCardInfo, 0, len(cards))
+	for _, card := range cards {
+		info := GiftCardInfo{GiftCard: card}
+		info.ExpireTime, info.DaysLeft, info.ExpiryStatus = giftCardExpiry(card.ExpireDate, giftCardPending(card.Status, card.UseStatus, card.PlatformSended), now)
+		infos = append(infos, info)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		a, b := infos[i].ExpireDate, infos[j].ExpireDate
+		if a <= 0 || b <= 0 {
+			return a > 0 && b <= 0
+		}
+		return a < b
+	})
+	return infos
+}
+
+// notifyExpiringGiftCards 对即将到期的礼品卡发送提醒通知，没有客户端连接时不发送，下次检查时再提醒
+func notifyExpiringGiftCards(infos []GiftCardInfo) {
+	if notificationManager.ClientCount() == 0 {
+		return
+	}
+	notifiedMutex.Lock()
+	defer notifiedMutex.Unlock()
+	for _, info := range infos {
+		if info.ExpiryStatus != GiftCardExpiring || notifiedGiftCards[info.CardId] {
+			continue
+		}
+		notifiedGiftCards[info.CardId] = true
+		SendGiftCardExpiring(strconv.FormatInt(info.CardId, 10), info.Name, info.DaysLeft)
+	}
+}
+
+// fetchGiftCards 获取我的礼品卡并发送到期提醒
+func fetchGiftCards() (cards services.UserGiftCards, infos []GiftCardInfo, err error) {
+	cards, err = Instance.UserGiftCards()
+	if err != nil {
+		return
+	}
+	infos = giftCardInfos(cards.GiftCards)
+	notifyExpiringGiftCards(infos)
+	return
+}
+
+// watchGiftCards 启动后定期检查礼品卡，即将到期时发送提醒；提醒天数小于 0 时不检查，未登录时跳过检查
+func watchGiftCards() {
+	if giftCardWarnDays() < 0 {
+		return
+	}
+	// 等待浏览器打开并连接通知
+	time.Sleep(giftCardCheckDelay)
+	for {
+		if services.Token != "" {
+			if _, _, err := fetchGiftCards(); err != nil {
+				log.Printf("检查礼品卡到期失败: %v", err)
+			}
+		}
+		time.Sleep(giftCardCheckInterval)
+	}
+}
+
+// genGiftCardReport 生成礼品卡到期报告 CSV，不包含兑换码
+func genGiftCardReport(fileName string, infos []GiftCardInfo) error {
+	var sb strings.Builder
+	// 写入 BOM，Excel 打开时不乱码
+	sb.WriteString("\ufeff")
+	w := csv.NewWriter(&sb)
+	_ = w.Write([]string{"卡片ID", "名称", "类型", "状态", "使用状态", "可自用", "到期时间", "剩余天数", "到期状态"})
+	for _, info := range infos {
+		daysLeft := ""
+		if info.ExpireDate > 0 && info.DaysLeft >= 0 {
+			daysLeft = utils.Int2String(info.DaysLeft)
+		}
+		_ = w.Write([]string{
+			strconv.FormatInt(info.CardId, 10),
+			info.Name,
+			info.CardTypeName,
+			utils.Int2String(info.Status),
+			utils.Int2String(info.UseStatus),
+			utils.Bool2String(info.SelfUsable),
+			info.ExpireTime,
+			daysLeft,
+			info.ExpiryStatus,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return utils.SaveFile(fileName, sb.String())
+}
+
+// handleGetGiftCards 我的礼品卡列表，expiryStatus 为 valid、expiring、expired、none、handled 时只返回对应状态的卡片
+func handleGetGiftCards(c *gin.Context) {
+	expiryStatus := c.Query("expiryStatus")
+	switch expiryStatus {
+	case "", GiftCardValid, GiftCardExpiring, GiftCardExpired, GiftCardNoExpiry, GiftCardHandled:
+	default:
+		Error(c, fmt.Errorf("不支持的到期状态: %s", expiryStatus))
+		return
+	}
+
+	cards, infos, err := fetchGiftCards()
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	if expiryStatus != "" {
+		filtered := make([]GiftCardInfo, 0, len(infos))
+		for _, info := range infos {
+			if info.ExpiryStatus == expiryStatus {
+				filtered = append(filtered, info)
+			}
+		}
+		infos = filtered
+	}
+	Success(c, gin.H{
+		"expiredText":   cards.ExpiredText,
+		"receivedText":  cards.ReceivedText,
+		"sendableCount": cards.SendableCount,
+		"warnDays":      giftCardWarnDays(),
+		"giftCards":     infos,
+	})
+}
+
+// handleGetGiftCardConfig 礼品卡配置
+func handleGetGiftCardConfig(c *gin.Context) {
+	list, err := Instance.GiftCardConfig()
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, list)
+}
+
+// handleGetGiftCardDetail 礼品卡详情，包含分享链接和祝福语，code 为可选的兑换码
+func handleGetGiftCardDetail(c *gin.Context) {
+	cardId, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || cardId <= 0 {
+		Error(c, fmt.Errorf("礼品卡ID无效: %s", c.Param("id")))
+		return
+	}
+	detail, err := Instance.GiftCardDetail(cardId, c.Query("code"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	info := GiftCardDetailInfo{GiftCardDetail: detail}
+	info.ExpireTime, info.DaysLeft, info.ExpiryStatus = giftCardExpiry(detail.ExpireDate, giftCardPending(detail.Status, detail.UseStatus, detail.PlatformSended), time.Now())
+	Success(c, info)
+}
+
+// handleExportGiftCardReport 导出礼品卡到期报告，按到期时间排序，保存到输出目录的礼品卡目录下
+func handleExportGiftCardReport(c *gin.Context) {
+	_, infos, err := fetchGiftCards()
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	filePath, err := utils.Mkdir(OutputDir, giftCardSubDir)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	fileName := filepath.Join(filePath, utils.FileName("礼品卡到期报告-"+time.Now().Format("20060102"), "csv"))
+	if err = genGiftCardReport(fileName, infos); err != nil {
+		Error(c, err)
+		return
+	}
+
+	expiring := 0
+	for _, info := range infos {
+		if info.ExpiryStatus == GiftCardExpiring {
+			expiring++
+		}
+	}
+	Success(c, gin.H{"file": fileName, "total": len(infos), "expiring": expiring})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yann0917/fs-gui/config"
+)
+
+func TestGiftCardExpiry(t *testing.T) {
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)
+	at := func(d time.Duration) int64 { return now.Add(d).UnixMilli() }
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name         string
+		warnDays     int
+		expireDate   int64
+		pending      bool
+		wantDaysLeft int
+		wantStatus   string
+	}{
+		{"当天到期", 0, at(10 * time.Hour), true, 0, GiftCardExpiring},
+		{"明天凌晨到期", 0, at(14*time.Hour + time.Minute), true, 1, GiftCardExpiring},
+		{"提醒天数内", 0, at(7 * day), true, 7, GiftCardExpiring},
+		{"提醒天数外", 0, at(8 * day), true, 8, GiftCardValid},
+		{"自定义提醒天数", 30, at(20 * day), true, 20, GiftCardExpiring},
+		{"已过期", 0, at(-time.Hour), true, -1, GiftCardExpired},
+		{"到期时刻", 0, at(0), true, -1, GiftCardExpired},
+		{"已赠送或已使用", 0, at(2 * day), false, 2, GiftCardHandled},
+		{"已赠送且已过期", 0, at(-day), false, -1, GiftCardHandled},
+		{"不提醒", -1, at(2 * day), true, 2, GiftCardValid},
+		{"不提醒时仍标记已过期", -1, at(-day), true, -1, GiftCardExpired},
+		{"没有到期时间", 0, 0, true, 0, GiftCardNoExpiry},
+	}
+	defer func(warnDays int) { config.Conf.GiftCardWarnDays = warnDays }(config.Conf.GiftCardWarnDays)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Conf.GiftCardWarnDays = tt.warnDays
+			expireTime, daysLeft, status := giftCardExpiry(tt.expireDate, tt.pending, now)
+			if daysLeft != tt.wantDaysLeft || status != tt.wantStatus {
+				t.Errorf("giftCardExpiry() = %d, %q, want %d, %q", daysLeft, status, tt.wantDaysLeft, tt.wantStatus)
+			}
+			if (expireTime != "") != (tt.expireDate > 0) {
+				t.Errorf("giftCardExpiry() expireTime = %q", expireTime)
+			}
+		})
+	}
+}
+
+func TestGiftCardPending(t *testing.T) {
+	tests := []struct {
+		status, useStatus int
+		platformSended    bool
+		want              bool
+	}{
+		{0, 0, false, true},
+		{1, 0, false, false},
+		{0, 1, false, false},
+		{0, 0, true, false},
+	}
+	for _, tt := range tests {
+		if got := giftCardPending(tt.status, tt.useStatus, tt.platformSended); got != tt.want {
+			t.Errorf("giftCardPending(%d, %d, %v) = %v, want %v", tt.status, tt.useStatus, tt.platformSended, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+)
+
+// knowledgeListParam 解析 app 端课程列表参数：categoryIds[] 分类，bookReadStatus 学习状态，viewType，pageNo，pageSize
+func knowledgeListParam(c *gin.Context) (param services.KnowledgeListParam, err error) {
+	for _, idStr := range c.QueryArray("categoryIds[]") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return param, fmt.Errorf("分类 id 无效: %s", idStr)
+		}
+		param.CategoryIds = append(param.CategoryIds, id)
+	}
+	param.PageNo, _ = strconv.Atoi(c.DefaultQuery("pageNo", "1"))
+	param.PageSize, _ = strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if param.PageNo < 1 || param.PageSize < 1 || param.PageSize > 100 {
+		return param, fmt.Errorf("分页参数无效")
+	}
+	param.ViewType, _ = strconv.Atoi(c.Query("viewType"))
+	if status := c.Query("bookReadStatus"); status != "" {
+		if param.BookReadStatus, err = strconv.Atoi(status); err != nil || param.BookReadStatus < 0 {
+			return param, fmt.Errorf("bookReadStatus 参数无效: %s", status)
+		}
+	}
+	return
+}
+
+// knowledgeCourses 转换为 web 端课程列表结构，课程详情和下载沿用 /api/courses 下的接口
+func knowledgeCourses(list []services.Knowledge) []services.Course {
+	courses := make([]services.Course, 0, len(list))
+	for _, knowledge := range list {
+		courses = append(courses, knowledge.Course())
+	}
+	return courses
+}
+
+func handleGetKnowledgeList(c *gin.Context) {
+	param, err := knowledgeListParam(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	list, err := Instance.KnowledgeList(param)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, knowledgeCourses(list))
+}
+
+func handleGetKnowledgeMainList(c *gin.Context) {
+	param, err := knowledgeListParam(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	list, err := Instance.KnowledgeMainList(param)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, knowledgeCourses(list))
+}
+
+// handleGetKnowledgeMarketInfo 课程运营信息，type 为运营位类型
+func handleGetKnowledgeMarketInfo(c *gin.Context) {
+	var param services.KnowledgeMarketInfoParam
+	if t := c.Query("type"); t != "" {
+		var err error
+		if param.Type, err = strconv.Atoi(t); err != nil {
+			Error(c, fmt.Errorf("type 参数无效: %s", t))
+			return
+		}
+	}
+	info, err := Instance.KnowledgeMarketInfo(param)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, info)
+}
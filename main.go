@@ -7,6 +7,7 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
 	"os/exec"
 	"runtime"
 
@@ -24,8 +25,22 @@ func init() {
 }
 
 func main() {
+	// 命令行校验已下载的音视频文件
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerifyCommand(os.Args[2:])
+		return
+	}
+	// 保存接口返回的文稿作为 Html2Md 的测试用例
+	if len(os.Args) > 1 && os.Args[1] == "capture" {
+		runCaptureCommand(os.Args[2:])
+		return
+	}
+
 	r := InitRouter()
 
+	// 定期检查即将到期的礼品卡
+	go watchGiftCards()
+
 	// 自动打开浏览器
 	go openBrowser("http://localhost:8080")
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// 思维导图合并方式
+const (
+	MindmapBundlePng = "png" // 纵向拼接为一张长图
+	MindmapBundlePdf = "pdf" // 每张图一页，页眉为书名
+)
+
+// mindmapImageSrcs 思维导图模块中的图片地址
+func mindmapImageSrcs(content string) (srcs []string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return
+	}
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		if src := s.AttrOr("data-src", s.AttrOr("src", "")); src != "" {
+			srcs = append(srcs, src)
+		}
+	})
+	return
+}
+
+// mindmapBundleFile 合并后的思维导图文件名，basePath 为不含扩展名的单张图片路径
+func mindmapBundleFile(basePath, bundle string) string {
+	return basePath + ".思维导图." + bundle
+}
+
+// mindmapExists 思维导图是否已下载：需要单张图片时检查第一张图片，需要合并时检查合并后的文件
+func mindmapExists(basePath string, opt DownloadOptions) bool {
+	if opt.MindmapImages || opt.MindmapBundle == "" {
+		if _, ok := utils.FindImageFile(basePath); !ok {
+			return false
+		}
+	}
+	return opt.MindmapBundle == "" || utils.CheckFileExist(mindmapBundleFile(basePath, opt.MindmapBundle))
+}
+
+// genMindmapBundle 将思维导图的多张图片合并为一张长图或一个 PDF
+func genMindmapBundle(basePath string, detail services.BookContent, content string, opt DownloadOptions) error {
+	srcs := mindmapImageSrcs(content)
+	if len(srcs) == 0 {
+		return fmt.Errorf("思维导图中没有图片")
+	}
+	fileName := mindmapBundleFile(basePath, opt.MindmapBundle)
+	title := strings.TrimSpace(detail.BookInfo.Title)
+
+	if opt.MindmapBundle == MindmapBundlePdf {
+		pdf, err := bookPdfOption(fileName, detail, opt)
+		if err != nil {
+			return err
+		}
+		profile := pdf.Profile
+		if profile.HeaderLeft == "" && profile.HeaderCenter == "" && profile.HeaderRight == "" {
+			pdf.Profile.HeaderCenter = "{title}"
+		}
+		pdf.Profile.Cover = false
+		pdf.Profile.Toc = false
+
+		var sb strings.Builder
+		sb.WriteString("<h1>" + html.EscapeString(title) + "</h1>")
+		for i, src := range srcs {
+			img := `<p style="text-align:center"><img src="` + html.EscapeString(src) + `" alt="思维导图"/></p>`
+			if i > 0 {
+				img = `<div class="page-break">` + img + "</div>"
+			}
+			sb.WriteString(img)
+		}
+		return utils.Html2Pdf(pdf, sb.String())
+	}
+
+	var images [][]byte
+	for _, src := range srcs {
+		data, err := utils.FetchBytes(src)
+		if err == nil {
+			data, err = utils.ConvertWebp(data)
+		}
+		if err != nil {
+			return fmt.Errorf("下载思维导图失败: %v", err)
+		}
+		images = append(images, data)
+	}
+	data, err := utils.StitchImages(images)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err = os.WriteFile(fileName, data, 0644); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
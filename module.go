@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// 模块内容类型
+const (
+	ModuleContentText  = "text"  // 文字稿，可导出 Markdown、PDF
+	ModuleContentImage = "image" // 只有图片，如思维导图，导出图片
+	ModuleContentEmpty = "empty"
+)
+
+// 模块导出格式
+const (
+	ModuleFormatMd    = "md"
+	ModuleFormatPdf   = "pdf"
+	ModuleFormatImage = "image"
+)
+
+// BookModuleInfo 书籍中可获取内容的模块，来自书籍详情的 articles
+type BookModuleInfo struct {
+	ModuleCode string `json:"moduleCode"`
+	ModuleName string `json:"moduleName"`
+	FragmentId int    `json:"fragmentId"`
+	Type       int    `json:"type"`
+	ShowFlag   bool   `json:"showFlag"`
+}
+
+// bookModules 列出书籍中可获取内容的模块
+// moduleList 只有模块名称没有 fragmentId，无法获取内容，只用于补全 articles 中缺少的模块名称
+func bookModules(detail services.BookContent) (modules []BookModuleInfo) {
+	names := make(map[string]string)
+	for _, module := range detail.ModuleList {
+		names[module.KeyWord] = module.Name
+	}
+	for _, article := range detail.Articles {
+		if article.FragmentId <= 0 {
+			continue
+		}
+		name := article.ModuleName
+		if name == "" {
+			name = names[article.ModuleCode]
+		}
+		modules = append(modules, BookModuleInfo{
+			ModuleCode: article.ModuleCode,
+			ModuleName: name,
+			FragmentId: article.FragmentId,
+			Type:       article.Type,
+			ShowFlag:   article.ShowFlag == 1,
+		})
+	}
+	return
+}
+
+// moduleContentType 根据模块内容判断类型：有文字时为文字稿，只有图片时为图片
+func moduleContentType(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return ModuleContentEmpty
+	}
+	if strings.TrimSpace(doc.Text()) != "" {
+		return ModuleContentText
+	}
+	if doc.Find("img").Length() > 0 {
+		return ModuleContentImage
+	}
+	return ModuleContentEmpty
+}
+
+// saveContentImages 保存内容中的所有图片，第一张为 name，之后依次为 name_1、name_2...
+func saveContentImages(filePath, name, content string) (err error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return
+	}
+	doc.Find("img").Each(func(i int, selection *goquery.Selection) {
+		basePath := filepath.Join(filePath, utils.FileName(name, ""))
+		if i > 0 {
+			basePath = filepath.Join(filePath, utils.FileName(name+"_"+utils.Int2String(i), ""))
+		}
+		if src, ok := selection.Attr("src"); ok {
+			_, err = utils.DownloadImage(basePath, src)
+		}
+	})
+	return
+}
+
+// findBookModule 按 fragmentId 查找书籍的模块
+func findBookModule(detail services.BookContent, fragmentID int) (module BookModuleInfo, err error) {
+	for _, module = range bookModules(detail) {
+		if module.FragmentId == fragmentID {
+			return
+		}
+	}
+	return module, fmt.Errorf("书籍 %s 没有可下载的模块: %d", strings.TrimSpace(detail.BookInfo.Title), fragmentID)
+}
+
+// DownloadModule 下载书籍的任一模块，format 为空时按内容类型选择：文字稿导出 Markdown，图片导出图片
+func DownloadModule(detail services.BookContent, businessType int, module BookModuleInfo, format string, opt DownloadOptions) (err error) {
+	bookID := detail.BookInfo.BookId
+	bookName := strings.TrimSpace(detail.BookInfo.Title)
+
+	id := utils.Int2String(bookID) + ":" + module.ModuleCode
+	title := bookName + " - " + module.ModuleName
+	SendDownloadStarted(id, "module", title)
+	defer func() {
+		if err != nil {
+			SendDownloadFailed(id, "module", title, err.Error())
+		} else {
+			SendDownloadCompleted(id, "module", title)
+		}
+	}()
+
+	content, err := Instance.BookModuleContent(bookID, module.FragmentId)
+	if err != nil {
+		return
+	}
+	contentType := moduleContentType(content.Content)
+	if contentType == ModuleContentEmpty {
+		return fmt.Errorf("模块【%s】没有内容", module.ModuleName)
+	}
+	if format == "" {
+		format = ModuleFormatMd
+		if contentType == ModuleContentImage {
+			format = ModuleFormatImage
+		}
+	}
+	if contentType == ModuleContentImage && format != ModuleFormatImage {
+		return fmt.Errorf("模块【%s】只有图片，只能导出图片", module.ModuleName)
+	}
+
+	if businessType == 0 {
+		businessType = detail.BookInfo.BusinessType
+	}
+	filePath, err := utils.Mkdir(OutputDir, utils.FileName(getSubDir(businessType), ""))
+	if err != nil {
+		return
+	}
+	name := utils.Int2String(bookID) + "." + bookName + "." + module.ModuleName
+
+	switch format {
+	case ModuleFormatImage:
+		return saveContentImages(filePath, name, content.Content)
+	case ModuleFormatMd:
+		fileName := filepath.Join(filePath, utils.FileName(name, "md"))
+		html := content.Content
+		if opt.LocalImages {
+			var failed []string
+			html, failed = utils.LocalizeMdImages(html, fileName)
+			for _, src := range failed {
+				fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败，文稿中将保留原地址\n", src)
+			}
+		}
+		return utils.SaveFile(fileName, utils.Html2Md(html))
+	case ModuleFormatPdf:
+		pdf, err := bookPdfOption(filepath.Join(filePath, utils.FileName(name, "pdf")), detail, opt)
+		if err != nil {
+			return err
+		}
+		pdf.Title = title
+		return utils.Html2Pdf(pdf, pdfArticleHtml(content.Content))
+	}
+	return fmt.Errorf("不支持的模块导出格式: %s", format)
+}
+
+// handleGetBookModules 书籍中可下载的模块
+func handleGetBookModules(c *gin.Context) {
+	bookId, _ := strconv.Atoi(c.Param("id"))
+	detail, err := Instance.BookContent(bookId)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, bookModules(detail))
+}
+
+// handleDownloadBookModule 下载书籍模块，fragmentId 指定模块，format 为 md、pdf 或 image，为空时按内容类型选择
+func handleDownloadBookModule(c *gin.Context) {
+	bookId, _ := strconv.Atoi(c.Param("id"))
+	fragmentId, _ := strconv.Atoi(c.Query("fragmentId"))
+	if fragmentId <= 0 {
+		Error(c, fmt.Errorf("请指定模块的 fragmentId"))
+		return
+	}
+	format := c.Query("format")
+	switch format {
+	case "", ModuleFormatMd, ModuleFormatPdf, ModuleFormatImage:
+	default:
+		Error(c, fmt.Errorf("不支持的模块导出格式: %s", format))
+		return
+	}
+	opt, err := parseDownloadOptions(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	detail, err := Instance.BookContent(bookId)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	module, err := findBookModule(detail, fragmentId)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	businessType, _ := strconv.Atoi(c.Query("businessType"))
+	go DownloadModule(detail, businessType, module, format, opt)
+	Success(c, nil)
+}
@@ -13,8 +13,8 @@ import (
 // DownloadNotification 下载通知结构
 type DownloadNotification struct {
 	ID        string `json:"id"`
-	Type      string `json:"type"`   // "book" | "course"
-	Status    string `json:"status"` // "started" | "progress" | "completed" | "failed"
+	Type      string `json:"type"`   // "book" | "course" | "giftcard"
+	Status    string `json:"status"` // "started" | "progress" | "completed" | "failed" | "expiring"
 	Title     string `json:"title"`
 	Message   string `json:"message"`
 	Progress  int    `json:"progress,omitempty"`
@@ -57,6 +57,13 @@ func (nm *NotificationManager) RemoveClient(clientID string) {
 	}
 }
 
+// ClientCount 当前连接的客户端数量
+func (nm *NotificationManager) ClientCount() int {
+	nm.mutex.RLock()
+	defer nm.mutex.RUnlock()
+	return len(nm.clients)
+}
+
 // SendNotification 发送通知给所有客户端
 func (nm *NotificationManager) SendNotification(notification DownloadNotification) {
 	nm.mutex.RLock()
@@ -141,6 +148,22 @@ func SendCourseItemCompleted(courseID, downloadType, courseTitle, itemTitle stri
 	notificationManager.SendNotification(notification)
 }
 
+// SendGiftCardExpiring 发送礼品卡即将到期通知
+func SendGiftCardExpiring(cardID, name string, daysLeft int) {
+	message := fmt.Sprintf("将在 %d 天后到期，请尽快赠送", daysLeft)
+	if daysLeft == 0 {
+		message = "今天到期，请尽快赠送"
+	}
+	notification := DownloadNotification{
+		ID:      cardID,
+		Type:    "giftcard",
+		Status:  "expiring",
+		Title:   name,
+		Message: message,
+	}
+	notificationManager.SendNotification(notification)
+}
+
 // handleSSENotifications 处理 SSE 连接
 func handleSSENotifications(c *gin.Context) {
 	// 设置 SSE 响应头
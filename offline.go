@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// offlineHeaderHtml 离线页面顶部信息：封面、标题、说明和本地音频
+// meta 为空的项不显示，audioFile 为空或不存在时不显示播放器
+func offlineHeaderHtml(cover, title string, meta []string, summary, audioFile string) string {
+	var sb strings.Builder
+	if cover != "" {
+		sb.WriteString(`<img class="cover" src="` + html.EscapeString(cover) + `" alt=""/>`)
+	}
+	sb.WriteString("<div>")
+	sb.WriteString("<h1>" + html.EscapeString(title) + "</h1>")
+	for _, item := range meta {
+		if item != "" {
+			sb.WriteString(`<p class="meta">` + html.EscapeString(item) + "</p>")
+		}
+	}
+	if summary != "" {
+		sb.WriteString(`<p class="summary">` + html.EscapeString(summary) + "</p>")
+	}
+	if audioFile != "" && utils.CheckFileExist(audioFile) {
+		// 音频与页面在同一目录，使用相对地址
+		href := (&url.URL{Path: filepath.Base(audioFile)}).String()
+		sb.WriteString(`<audio controls preload="none" src="` + href + `"></audio>`)
+		sb.WriteString(`<p class="meta"><a href="` + href + `">` + html.EscapeString(filepath.Base(audioFile)) + "</a></p>")
+	}
+	sb.WriteString("</div>")
+	return sb.String()
+}
+
+// mindmapHtml 提取思维导图模块中的图片
+func mindmapHtml(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src := s.AttrOr("data-src", s.AttrOr("src", ""))
+		if src != "" {
+			sb.WriteString(`<img src="` + html.EscapeString(src) + `" alt="思维导图"/>`)
+		}
+	})
+	if sb.Len() == 0 {
+		return ""
+	}
+	return `<h2>思维导图</h2><div class="mindmap">` + sb.String() + "</div>"
+}
+
+// genBookHtml 生成书籍的离线 HTML：书籍信息、文稿和思维导图，同目录下有音频时可直接播放
+func genBookHtml(fileName string, detail services.BookContent, article, mindmap string) error {
+	info := detail.BookInfo
+	meta := []string{"讲者：" + info.SpeakerName}
+	if info.Score != "" {
+		meta = append(meta, "评分："+info.Score)
+	}
+	audioFile := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + getFileSuffix(1)
+
+	page := utils.OfflineHtml{
+		Title:   strings.TrimSpace(info.Title),
+		Header:  offlineHeaderHtml(info.CoverImg, strings.TrimSpace(info.Title), meta, info.Summary, audioFile),
+		Content: pdfArticleHtml(article) + mindmapHtml(mindmap),
+	}
+	return page.Write(fileName)
+}
+
+// genProgramHtml 生成课程节目的离线 HTML
+func genProgramHtml(fileName string, courseID int, course services.CourseInfo, program services.Program) error {
+	programDetail, err := Instance.ProgramDetail(services.ProgramDetailParam{
+		AlbumId:    courseID,
+		ProgramId:  program.Id,
+		FragmentId: program.FragmentId,
+	})
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(programDetail.Content) == "" {
+		return fmt.Errorf("无文稿")
+	}
+
+	cover := program.TitleImageUrl
+	if cover == "" {
+		cover = course.AlbumCoverUrl
+	}
+	meta := []string{course.Title}
+	if course.Author != "" {
+		meta = append(meta, "讲者："+course.Author)
+	}
+	title := strings.TrimSpace(program.Title)
+	audioFile := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + getFileSuffix(1)
+
+	page := utils.OfflineHtml{
+		Title:   title,
+		Header:  offlineHeaderHtml(cover, title, meta, "", audioFile),
+		Content: pdfArticleHtml(programDetail.Content),
+	}
+	return page.Write(fileName)
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// bookCoverHtml 根据书籍信息生成 PDF 封面页
+func bookCoverHtml(detail services.BookContent) string {
+	info := detail.BookInfo
+	var sb strings.Builder
+	sb.WriteString(`<div class="book-cover">`)
+	if info.CoverImg != "" {
+		sb.WriteString(`<img src="` + html.EscapeString(info.CoverImg) + `"/>`)
+	}
+	sb.WriteString("<h1>" + html.EscapeString(strings.TrimSpace(info.Title)) + "</h1>")
+	var authors []string
+	for _, author := range detail.Authors {
+		authors = append(authors, author.Name)
+	}
+	if len(authors) > 0 {
+		sb.WriteString(`<p>作者：` + html.EscapeString(strings.Join(authors, "、")) + "</p>")
+	}
+	if info.SpeakerName != "" {
+		sb.WriteString(`<p>讲者：` + html.EscapeString(info.SpeakerName) + "</p>")
+	}
+	var meta []string
+	if info.Score != "" {
+		meta = append(meta, "评分 "+info.Score)
+	}
+	if info.PublishTime > 0 {
+		meta = append(meta, utils.UnixMilli2DateString(info.PublishTime))
+	}
+	if len(meta) > 0 {
+		sb.WriteString(`<p class="meta">` + html.EscapeString(strings.Join(meta, "　")) + "</p>")
+	}
+	sb.WriteString("</div>")
+	return sb.String()
+}
+
+// pdfArticleHtml 提取文稿正文，并修正导致 wkhtmltopdf 文字被截断的 letter-spacing
+func pdfArticleHtml(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	s := doc.Find("div.rich_media_content").First()
+	if s.Length() == 0 {
+		s = doc.Find("body")
+	}
+	s.Find("section, p, span").Each(func(i int, item *goquery.Selection) {
+		replaceLetterSpacing(item)
+	})
+	res, _ := s.Html()
+	return res
+}
+
+// bookPdfOption 书籍 PDF 的生成选项，版式由下载选项指定
+func bookPdfOption(fileName string, detail services.BookContent, opt DownloadOptions) (pdf utils.PdfOption, err error) {
+	pdf.Profile, err = utils.GetPdfProfile(opt.PdfProfile)
+	if err != nil {
+		return
+	}
+	pdf.FileName = fileName
+	pdf.Engine = opt.PdfEngine
+	pdf.Title = strings.TrimSpace(detail.BookInfo.Title)
+	pdf.Author = detail.BookInfo.SpeakerName
+	pdf.CoverHtml = bookCoverHtml(detail)
+	return
+}
+
+// handleGetPdfProfiles 可用的 PDF 版式
+func handleGetPdfProfiles(c *gin.Context) {
+	Success(c, utils.PdfProfiles())
+}
+
+// anthologySubDir 合集 PDF 的保存目录
+const anthologySubDir = "合集"
+
+// demoteHeadings 标题降一级，合集中书名使用 h1，文稿标题从 h2 开始
+func demoteHeadings(content string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content
+	}
+	for level := 5; level >= 1; level-- {
+		tag := "h" + strconv.Itoa(level+1)
+		doc.Find("h" + strconv.Itoa(level)).Each(func(i int, s *goquery.Selection) {
+			for _, n := range s.Nodes {
+				n.Data = tag
+				n.DataAtom = 0
+			}
+		})
+	}
+	res, _ := doc.Find("body").Html()
+	return res
+}
+
+// anthologyBookHtml 合集中的一本书：依次为封面、书名、讲者、简介和文稿，
+// 第一本书之后的书从新页开始，避免目录或封面后出现空白页
+func anthologyBookHtml(detail services.BookContent, first bool) (string, error) {
+	var sb strings.Builder
+	if first {
+		sb.WriteString("<div>")
+	} else {
+		sb.WriteString(`<div class="page-break">`)
+	}
+	sb.WriteString(bookCoverHtml(detail))
+	if summary := detail.BookInfo.Summary; summary != "" {
+		sb.WriteString(`<p class="summary">` + html.EscapeString(summary) + "</p>")
+	}
+	sb.WriteString("</div>")
+
+	var fragmentID int
+	for _, article := range detail.Articles {
+		if article.ModuleCode == "articles" {
+			fragmentID = article.FragmentId
+		}
+	}
+	if fragmentID == 0 {
+		sb.WriteString("<p>无解读文稿</p>")
+		return sb.String(), nil
+	}
+	module, err := Instance.BookModuleContent(detail.BookInfo.BookId, fragmentID)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString(`<div class="page-break">` + demoteHeadings(pdfArticleHtml(module.Content)) + "</div>")
+	return sb.String(), nil
+}
+
+// DownloadAnthology 将多本书的文稿按顺序合并为一个 PDF，带全局目录和每本书的书签
+func DownloadAnthology(title string, bookIDs []int, opt DownloadOptions) (err error) {
+	id := "anthology:" + title
+	SendDownloadStarted(id, "anthology", title)
+	defer func() {
+		if err != nil {
+			SendDownloadFailed(id, "anthology", title, err.Error())
+		} else {
+			SendDownloadCompleted(id, "anthology", title)
+		}
+	}()
+
+	filePath, err := utils.Mkdir(OutputDir, anthologySubDir)
+	if err != nil {
+		return
+	}
+	pdf, err := utils.GetPdfProfile(opt.PdfProfile)
+	if err != nil {
+		return
+	}
+
+	var sb strings.Builder
+	count := 0
+	for i, bookID := range bookIDs {
+		detail, err := Instance.BookContent(bookID)
+		if err == nil {
+			var content string
+			if content, err = anthologyBookHtml(detail, count == 0); err == nil {
+				sb.WriteString(content)
+				count++
+			}
+		}
+		if err != nil {
+			fmt.Printf("【\033[31;1m%d\033[0m】获取书籍失败: %v\n", bookID, err)
+		}
+		SendDownloadProgress(id, "anthology", title, i+1, len(bookIDs))
+	}
+	if count == 0 {
+		return fmt.Errorf("没有可以合并的书籍")
+	}
+
+	// 合集使用全局目录，wkhtmltopdf 和内置引擎都会生成目录页，书名为一级目录；每本书自带封面页
+	pdf.Toc = true
+	pdf.Cover = false
+	return utils.Html2Pdf(utils.PdfOption{
+		FileName: filepath.Join(filePath, utils.FileName(title, "pdf")),
+		Profile:  pdf,
+		Engine:   opt.PdfEngine,
+		Title:    title,
+	}, sb.String())
+}
+
+// handleDownloadAnthology 下载合集 PDF：ids 指定书籍及顺序，或按 businessType、classifyIds[] 获取分类下的书籍
+func handleDownloadAnthology(c *gin.Context) {
+	opt, err := parseDownloadOptions(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	bookIDs, err := queryBookIDs(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	if len(bookIDs) == 0 {
+		Error(c, fmt.Errorf("请指定需要合并的书籍"))
+		return
+	}
+
+	title := strings.TrimSpace(c.Query("title"))
+	if title == "" {
+		title = anthologySubDir + "-" + time.Now().Format("20060102150405")
+	}
+	go DownloadAnthology(title, bookIDs, opt)
+	Success(c, gin.H{"title": title, "total": len(bookIDs)})
+}
+
+// maxQueryBooks 按分类获取书籍时最多获取的书籍数量
+const maxQueryBooks = 1000
+
+// queryBookIDs 解析请求中指定的书籍：ids 指定书籍及顺序，或按 businessType、classifyIds[] 获取分类下的书籍
+func queryBookIDs(c *gin.Context) (bookIDs []int, err error) {
+	if ids := c.Query("ids"); ids != "" {
+		for _, idStr := range strings.Split(ids, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(idStr))
+			if err != nil {
+				return nil, fmt.Errorf("书籍 id 无效: %s", idStr)
+			}
+			bookIDs = append(bookIDs, id)
+		}
+		return
+	}
+
+	param := services.ClassifyBookParam{PageSize: 100}
+	param.BusinessType, _ = strconv.Atoi(c.Query("businessType"))
+	param.SortType, _ = strconv.Atoi(c.Query("sortType"))
+	param.PublishYear, _ = strconv.Atoi(c.Query("publishYear"))
+	if pageSize, _ := strconv.Atoi(c.Query("pageSize")); pageSize > 0 {
+		param.PageSize = pageSize
+	}
+	for _, idStr := range c.QueryArray("classifyIds[]") {
+		if id, err := strconv.Atoi(idStr); err == nil {
+			param.ClassifyIds = append(param.ClassifyIds, id)
+		}
+	}
+	// 接口不返回总数，逐页获取直到某一页不足 pageSize 本
+	seen := make(map[int]bool)
+	for param.PageNo = 1; ; param.PageNo++ {
+		books, err := Instance.ClassifyBookList(param)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, book := range books {
+			if !seen[book.BookId] {
+				seen[book.BookId] = true
+				bookIDs = append(bookIDs, book.BookId)
+				added++
+			}
+		}
+		// 最后一页不足 pageSize 本，或整页都是已获取的书籍
+		if len(books) < param.PageSize || added == 0 {
+			return bookIDs, nil
+		}
+		if len(bookIDs) >= maxQueryBooks {
+			return nil, fmt.Errorf("分类下的书籍超过 %d 本，请缩小分类范围或通过 ids 指定书籍", maxQueryBooks)
+		}
+	}
+}
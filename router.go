@@ -2,8 +2,6 @@ package main
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -32,22 +30,53 @@ func InitRouter() *gin.Engine {
 		api.GET("/categories", handleGetCategories)
 		api.GET("/user", handleGetUserInfo)
 		api.GET("/notifications", handleSSENotifications)
+		api.GET("/verify", handleVerify)
+		api.GET("/verify/report", handleGetVerifyReport)
+		api.GET("/pdf/profiles", handleGetPdfProfiles)
 
 		books := api.Group("/books")
 		{
 			books.GET("", handleGetBooks)
 			books.GET("/:id", handleGetBookDetail)
 			books.GET("/:id/module", handleGetBookModuleDetail)
+			books.GET("/:id/modules", handleGetBookModules)
+			books.GET("/:id/modules/download", handleDownloadBookModule)
+			books.GET("/:id/comments", handleGetBookComments)
 			books.GET("/download", handleDownloadBook)
+			books.GET("/anthology", handleDownloadAnthology)
+			books.GET("/flashcards", handleDownloadFlashcards)
 		}
 
 		courses := api.Group("/courses")
 		{
 			courses.GET("", handleGetCourseList)
+			courses.GET("/categories", handleGetCourseCategories)
 			courses.GET("/:id", handleGetCourseDetail)
 			courses.GET("/:id/articles", handleGetArticleList)
 			courses.GET("/download", handleDownloadCourse)
 		}
+
+		// app 端课程目录，课程详情和下载使用 /courses 下的接口
+		knowledge := api.Group("/knowledge")
+		{
+			knowledge.GET("", handleGetKnowledgeList)
+			knowledge.GET("/main", handleGetKnowledgeMainList)
+			knowledge.GET("/market", handleGetKnowledgeMarketInfo)
+		}
+
+		speakers := api.Group("/speakers")
+		{
+			speakers.GET("/:id", handleGetSpeaker)
+			speakers.GET("/:id/download", handleDownloadSpeakerBooks)
+		}
+
+		giftCards := api.Group("/giftcards")
+		{
+			giftCards.GET("", handleGetGiftCards)
+			giftCards.GET("/config", handleGetGiftCardConfig)
+			giftCards.GET("/report", handleExportGiftCardReport)
+			giftCards.GET("/:id", handleGetGiftCardDetail)
+		}
 	}
 	return r
 }
@@ -131,6 +160,40 @@ func handleGetCategories(c *gin.Context) {
 	Success(c, categories)
 }
 
+// courseCategories 课程分类，即分类中带有 businessZone 的专区，zone 大于 0 时只返回指定专区
+func courseCategories(zone int) ([]services.Category, error) {
+	categories, err := Instance.BookClassify()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]services.Category, 0, len(categories))
+	for _, category := range categories {
+		if category.BusinessZone <= 0 && category.BusinessType != 4 {
+			continue
+		}
+		if zone > 0 && category.BusinessZone != zone {
+			continue
+		}
+		list = append(list, category)
+	}
+	return list, nil
+}
+
+// handleGetCourseCategories 课程分类，可通过 businessZone 只返回指定专区
+func handleGetCourseCategories(c *gin.Context) {
+	zone, err := queryInt(c, "businessZone", 0, 0)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	list, err := courseCategories(zone)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, list)
+}
+
 func handleGetUserInfo(c *gin.Context) {
 	user, err := Instance.GetUserInfo()
 	if err != nil {
@@ -195,6 +258,7 @@ func handleGetBookDetail(c *gin.Context) {
 		Error(c, err)
 		return
 	}
+	speakerCache.record(book)
 	Success(c, book)
 }
 
@@ -213,7 +277,6 @@ func handleGetBookModuleDetail(c *gin.Context) {
 func handleGetCourseList(c *gin.Context) {
 	pageNo := c.Query("pageNo")
 	pageSize := c.Query("pageSize")
-	sortType := c.Query("sortType")
 
 	var classifyIds []int
 	// 首先尝试获取数组格式的参数
@@ -230,9 +293,31 @@ func handleGetCourseList(c *gin.Context) {
 	var params services.CourseListParam
 	params.Page.PageNo, _ = strconv.Atoi(pageNo)
 	params.Page.PageSize, _ = strconv.Atoi(pageSize)
-	params.SortType, _ = strconv.Atoi(sortType)
-	params.Platform = 3
-	params.BusinessZone = 2
+	var err error
+	if params.SortType, err = queryOption(c, "sortType", 0, courseSortTypes); err != nil {
+		Error(c, err)
+		return
+	}
+	if params.Platform, err = queryOption(c, "platform", defaultCoursePlatform, coursePlatforms); err != nil {
+		Error(c, err)
+		return
+	}
+	if params.BusinessZone, err = queryInt(c, "businessZone", defaultCourseZone, 1); err != nil {
+		Error(c, err)
+		return
+	}
+	// 专区需为课程分类中的专区
+	if params.BusinessZone != defaultCourseZone {
+		zones, err := courseCategories(params.BusinessZone)
+		if err != nil {
+			Error(c, err)
+			return
+		}
+		if len(zones) == 0 {
+			Error(c, fmt.Errorf("不支持的课程专区: %d", params.BusinessZone))
+			return
+		}
+	}
 	params.ClassifyIds = classifyIds
 
 	list, err := Instance.CourseList(params)
@@ -282,8 +367,13 @@ func handleDownloadBook(c *gin.Context) {
 	bookId, _ := strconv.Atoi(id)
 	businessTypeInt, _ := strconv.Atoi(businessType)
 	downloadTypeInt, _ := strconv.Atoi(downloadType)
+	opt, err := parseDownloadOptions(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
 	// 调用下载方法
-	go Download(bookId, businessTypeInt, downloadTypeInt)
+	go Download(bookId, businessTypeInt, downloadTypeInt, opt)
 	Success(c, nil)
 
 }
@@ -293,10 +383,165 @@ func handleDownloadCourse(c *gin.Context) {
 	courseId, _ := strconv.Atoi(id)
 	downloadType := c.Query("downloadType")
 	downloadTypeInt, _ := strconv.Atoi(downloadType)
-	go DownloadCourse(courseId, downloadTypeInt)
+	opt, err := parseDownloadOptions(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	go DownloadCourse(courseId, downloadTypeInt, opt)
 	Success(c, nil)
 }
 
+// 课程列表未指定平台和专区时的默认值
+const (
+	defaultCoursePlatform = 3
+	defaultCourseZone     = 2
+)
+
+// 课程列表支持的排序方式和平台
+var (
+	courseSortTypes = map[int]bool{0: true, 1: true, 2: true} // 0-默认, 1-最新, 2-最热
+	coursePlatforms = map[int]bool{defaultCoursePlatform: true}
+)
+
+// queryInt 解析整数参数，未传参时返回默认值，小于 min 时返回错误
+func queryInt(c *gin.Context, key string, def, min int) (int, error) {
+	value := strings.TrimSpace(c.Query(key))
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("参数 %s 无效: %s", key, value)
+	}
+	return n, nil
+}
+
+// queryOption 解析取值有限的整数参数，未传参时返回默认值，不在 allowed 中时返回错误
+func queryOption(c *gin.Context, key string, def int, allowed map[int]bool) (int, error) {
+	n, err := queryInt(c, key, def, 0)
+	if err != nil {
+		return 0, err
+	}
+	if !allowed[n] {
+		return 0, fmt.Errorf("不支持的参数 %s: %d", key, n)
+	}
+	return n, nil
+}
+
+// 倍速副本支持的倍速范围
+const (
+	minTempo = 0.5
+	maxTempo = 4
+)
+
+// DownloadOptions 下载选项
+type DownloadOptions struct {
+	LoudnessTarget float64 // 响度标准化目标值(LUFS)，0 表示不处理
+	LoudnessMode   string  // 响度标准化方式: gain 或 tag
+	Tempo          float64 // 倍速副本的播放速度，0 表示不生成
+	PdfProfile     string  // PDF 版式名称，为空时使用配置文件中的默认版式
+	PdfEngine      string  // PDF 引擎，为空时使用配置文件中的设置
+	LocalImages    bool    // Markdown 文稿中的图片下载到本地 assets 目录
+	MindmapBundle  string  // 思维导图合并方式: png 或 pdf，为空时不合并
+	MindmapImages  bool    // 合并思维导图时是否同时保存单张图片
+	Comments       int     // 文稿末尾追加的评论数量，0 表示不追加；导出评论 CSV 时为导出数量
+	CommentTab     int     // 评论排序方式: 1-推荐, 2-最热, 3-最新
+	SubDir         string  // 保存目录，相对于输出目录，为空时按栏目保存；笔记库笔记(类型 9)始终保存在笔记库中
+}
+
+// defaultDownloadOptions 配置文件中的默认下载选项
+func defaultDownloadOptions() (opt DownloadOptions) {
+	opt.LoudnessTarget = config.Conf.LoudnessTarget
+	opt.LoudnessMode = config.Conf.LoudnessMode
+	opt.LocalImages = config.Conf.MdLocalImages
+	opt.MindmapBundle = config.Conf.MindmapBundle
+	opt.MindmapImages = true
+	opt.CommentTab = services.CommentTabRecommend
+	if opt.LoudnessMode == "" {
+		opt.LoudnessMode = utils.LoudnessModeGain
+	}
+	return
+}
+
+// parseDownloadOptions 解析下载选项，未传参时使用配置文件中的默认值
+func parseDownloadOptions(c *gin.Context) (opt DownloadOptions, err error) {
+	opt = defaultDownloadOptions()
+
+	if lufs := c.Query("lufs"); lufs != "" {
+		opt.LoudnessTarget, err = strconv.ParseFloat(lufs, 64)
+		if err != nil {
+			return opt, fmt.Errorf("lufs 参数无效: %s", lufs)
+		}
+	}
+	if opt.LoudnessTarget < -70 || opt.LoudnessTarget > 0 {
+		return opt, fmt.Errorf("目标响度需在 -70 到 0 LUFS 之间: %v", opt.LoudnessTarget)
+	}
+
+	if mode := c.Query("loudnessMode"); mode != "" {
+		opt.LoudnessMode = mode
+	}
+	if opt.LoudnessMode != utils.LoudnessModeGain && opt.LoudnessMode != utils.LoudnessModeTag {
+		return opt, fmt.Errorf("不支持的响度标准化方式: %s", opt.LoudnessMode)
+	}
+
+	if tempo := c.Query("tempo"); tempo != "" {
+		opt.Tempo, err = strconv.ParseFloat(tempo, 64)
+		if err != nil {
+			return opt, fmt.Errorf("tempo 参数无效: %s", tempo)
+		}
+		if opt.Tempo == 1 {
+			opt.Tempo = 0
+		}
+		if opt.Tempo != 0 && (opt.Tempo < minTempo || opt.Tempo > maxTempo) {
+			return opt, fmt.Errorf("倍速需在 %v 到 %v 之间: %v", minTempo, maxTempo, opt.Tempo)
+		}
+	}
+
+	if profile := c.Query("pdfProfile"); profile != "" {
+		if _, err = utils.GetPdfProfile(profile); err != nil {
+			return opt, err
+		}
+		opt.PdfProfile = profile
+	}
+	if engine := c.Query("pdfEngine"); engine != "" {
+		switch engine {
+		case utils.PdfEngineAuto, utils.PdfEngineWkhtmltopdf, utils.PdfEngineBuiltin:
+			opt.PdfEngine = engine
+		default:
+			return opt, fmt.Errorf("不支持的PDF引擎: %s", engine)
+		}
+	}
+	if localImages := c.Query("localImages"); localImages != "" {
+		opt.LocalImages, err = strconv.ParseBool(localImages)
+		if err != nil {
+			return opt, fmt.Errorf("localImages 参数无效: %s", localImages)
+		}
+	}
+	if bundle, ok := c.GetQuery("mindmapBundle"); ok {
+		opt.MindmapBundle = bundle
+	}
+	if opt.MindmapBundle != "" && opt.MindmapBundle != MindmapBundlePng && opt.MindmapBundle != MindmapBundlePdf {
+		return opt, fmt.Errorf("不支持的思维导图合并方式: %s", opt.MindmapBundle)
+	}
+	if images := c.Query("mindmapImages"); images != "" {
+		opt.MindmapImages, err = strconv.ParseBool(images)
+		if err != nil {
+			return opt, fmt.Errorf("mindmapImages 参数无效: %s", images)
+		}
+	}
+	if comments := c.Query("comments"); comments != "" {
+		opt.Comments, err = strconv.Atoi(comments)
+		if err != nil || opt.Comments < 0 || opt.Comments > 1000 {
+			return opt, fmt.Errorf("评论数量需在 0 到 1000 之间: %s", comments)
+		}
+	}
+	if opt.CommentTab, err = parseCommentTab(c.Query("commentTab")); err != nil {
+		return opt, err
+	}
+	return
+}
+
 func handleLogout(c *gin.Context) {
 	// 清除所有 cookies
 	for _, cookie := range c.Request.Cookies() {
@@ -314,11 +559,12 @@ func Error(c *gin.Context, err error) {
 	c.JSON(200, gin.H{"code": 1, "data": nil, "msg": err.Error()})
 }
 
-func Download(bookID, businessType, downloadType int) (err error) {
+func Download(bookID, businessType, downloadType int, opt DownloadOptions) (err error) {
 	detail, err := Instance.BookContent(bookID)
 	if err != nil {
 		return
 	}
+	speakerCache.record(detail)
 
 	bookName := strings.TrimSpace(detail.BookInfo.Title)
 	bookIDStr := utils.Int2String(bookID)
@@ -326,18 +572,50 @@ func Download(bookID, businessType, downloadType int) (err error) {
 	// 发送下载开始通知
 	SendDownloadStarted(bookIDStr, "book", bookName)
 
-	subDir := getSubDir(businessType)
+	if businessType == 0 {
+		businessType = detail.BookInfo.BusinessType
+	}
+	dir := utils.FileName(getSubDir(businessType), "")
+	if opt.SubDir != "" {
+		dir = opt.SubDir
+	}
 	fileSuffix := getFileSuffix(downloadType)
-	filePath, err := utils.Mkdir(OutputDir, utils.FileName(subDir, ""))
+	filePath, err := utils.Mkdir(OutputDir, dir)
 	if err != nil {
 		SendDownloadFailed(bookIDStr, "book", bookName, err.Error())
 		return
 	}
 
 	fileName := filepath.Join(filePath, utils.FileName(utils.Int2String(bookID)+"."+bookName, fileSuffix))
-	if utils.CheckFileExist(fileName) {
+	if downloadType == 9 {
+		// 笔记库中的笔记以 bookId.书名 命名，wikilink 显示为书名；
+		// 笔记需与索引笔记在同一个笔记库中，不使用 opt.SubDir
+		if fileName, err = vaultNotePath(bookID, bookName); err != nil {
+			SendDownloadFailed(bookIDStr, "book", bookName, err.Error())
+			return
+		}
+	}
+	exists := utils.CheckFileExist(fileName)
+	if downloadType == 5 {
+		// 思维导图按实际图片类型保存，扩展名不固定；合并时还需检查合并后的文件
+		exists = mindmapExists(strings.TrimSuffix(fileName, "."+fileSuffix), opt)
+	}
+	if exists && opt.Comments > 0 && commentDownloadTypes[downloadType] {
+		// 已有的文件可能没有评论或评论数量不同，指定了评论数量时重新生成
+		fmt.Printf("【\033[37;1m%s\033[0m】已存在，重新生成以包含评论\n", fileName)
+		exists = false
+	}
+	if exists {
 		fmt.Printf("【\033[37;1m%s\033[0m】已存在\n", fileName)
-		SendDownloadCompleted(bookIDStr, "book", bookName)
+		// 已下载的音频仍可补充生成倍速副本
+		if downloadType == 1 {
+			err = makeTempoCopy(fileName, detail.AudioInfo.Duration, opt)
+		}
+		if err != nil {
+			SendDownloadFailed(bookIDStr, "book", bookName, err.Error())
+		} else {
+			SendDownloadCompleted(bookIDStr, "book", bookName)
+		}
 		return
 	}
 
@@ -363,30 +641,19 @@ func Download(bookID, businessType, downloadType int) (err error) {
 	switch downloadType {
 	case 1:
 		// 获取封面图
-		var coverBytes []byte
-		if detail.AudioInfo.MediaCoverUrl != "" {
-			cover, err1 := http.Get(detail.AudioInfo.MediaCoverUrl)
-			if err1 != nil {
-				err = err1
-				return
-			}
-			defer cover.Body.Close()
-			coverBytes, err = io.ReadAll(cover.Body)
-			if err != nil {
-				return
-			}
-		}
+		coverBytes, coverMime := fetchCover(detail.AudioInfo.MediaCoverUrl)
 		rawURL := detail.AudioInfo.MediaUrl
 		if rawURL != "" {
 			ext, _ := utils.GetUrlExt(rawURL)
 			switch ext {
 			case ".mp3":
-				var opt utils.ID3Options
-				opt.Artist = detail.BookInfo.SpeakerName
-				opt.Title = bookName
-				opt.Album = getSubDir(detail.BookInfo.BusinessType)
-				opt.Cover = coverBytes
-				err = utils.DownloadAudio(fileName, rawURL, opt)
+				var id3 utils.ID3Options
+				id3.Artist = detail.BookInfo.SpeakerName
+				id3.Title = bookName
+				id3.Album = getSubDir(detail.BookInfo.BusinessType)
+				id3.Cover = coverBytes
+				id3.CoverMime = coverMime
+				err = utils.DownloadAudio(fileName, rawURL, id3)
 			case ".m3u8":
 				err = utils.MergeAudioAndVideo([]string{rawURL}, fileName)
 				if err != nil {
@@ -397,6 +664,12 @@ func Download(bookID, businessType, downloadType int) (err error) {
 			default:
 				fmt.Println(rawURL)
 			}
+			if err == nil && utils.CheckFileExist(fileName) {
+				err = normalizeLoudness(fileName, detail.AudioInfo.LoudnessNormalizationInfo, opt)
+			}
+			if err == nil && utils.CheckFileExist(fileName) {
+				err = makeTempoCopy(fileName, detail.AudioInfo.Duration, opt)
+			}
 		}
 	case 2:
 		rawURL := detail.VideoInfo.MediaUrl
@@ -404,6 +677,8 @@ func Download(bookID, businessType, downloadType int) (err error) {
 		if err != nil {
 			fmt.Println(rawURL)
 			fmt.Println(err)
+		} else {
+			err = normalizeLoudness(fileName, detail.VideoInfo.LoudnessNormalizationInfo, opt)
 		}
 	case 3:
 		if articleFragmentId > 0 {
@@ -411,7 +686,15 @@ func Download(bookID, businessType, downloadType int) (err error) {
 			if err1 != nil {
 				return err1
 			}
-			res := utils.Html2Md(module.Content)
+			content := module.Content
+			if opt.LocalImages {
+				var failed []string
+				content, failed = utils.LocalizeMdImages(content, fileName)
+				for _, src := range failed {
+					fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败，文稿中将保留原地址\n", src)
+				}
+			}
+			res := utils.Html2Md(appendComments(content, detail, opt))
 			err = utils.SaveFile(fileName, res)
 		} else {
 			fmt.Printf("【\033[31;1m%s\033[0m】无解读文稿\n", bookName)
@@ -423,21 +706,11 @@ func Download(bookID, businessType, downloadType int) (err error) {
 			if err1 != nil {
 				return err1
 			}
-			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(module.Content))
-			text := doc.Find("div.rich_media_content").Map(func(i int, s *goquery.Selection) string {
-				s.Find("section").Each(func(i int, item *goquery.Selection) {
-					replaceLetterSpacing(item)
-				})
-				s.Find("p").Each(func(index int, item *goquery.Selection) {
-					replaceLetterSpacing(item)
-				})
-				s.Find("span").Each(func(index int, item *goquery.Selection) {
-					replaceLetterSpacing(item)
-				})
-				res, _ := s.Html()
-				return res
-			})
-			err = utils.Html2Pdf(fileName, bookName, text[0])
+			pdf, err1 := bookPdfOption(fileName, detail, opt)
+			if err1 != nil {
+				return err1
+			}
+			err = utils.Html2Pdf(pdf, appendComments(pdfArticleHtml(module.Content), detail, opt))
 			if err != nil {
 				return err
 			}
@@ -450,28 +723,81 @@ func Download(bookID, businessType, downloadType int) (err error) {
 			if err1 != nil {
 				return err1
 			}
-			doc, _ := goquery.NewDocumentFromReader(strings.NewReader(module.Content))
 			name := utils.Int2String(bookID) + "." + bookName
-			doc.Find("img").Each(func(i int, selection *goquery.Selection) {
-				if i > 0 {
-					fileName = filepath.Join(filePath, utils.FileName(name+"_"+utils.Int2String(i), fileSuffix))
-				} else {
-					fileName = filepath.Join(filePath, utils.FileName(name, fileSuffix))
-				}
-				if src, ok := selection.Attr("src"); ok {
-					err = utils.Download(fileName, src)
-				}
-			})
+			basePath := filepath.Join(filePath, utils.FileName(name, ""))
+			if _, ok := utils.FindImageFile(basePath); !ok && (opt.MindmapImages || opt.MindmapBundle == "") {
+				err = saveContentImages(filePath, name, module.Content)
+			}
+			if err == nil && opt.MindmapBundle != "" && !utils.CheckFileExist(mindmapBundleFile(basePath, opt.MindmapBundle)) {
+				err = genMindmapBundle(basePath, detail, module.Content, opt)
+			}
 		} else {
 			fmt.Printf("【\033[31;1m%s\033[0m】无思维导图\n", bookName)
 		}
+	case 6:
+		if articleFragmentId > 0 {
+			module, err1 := Instance.BookModuleContent(bookID, articleFragmentId)
+			if err1 != nil {
+				return err1
+			}
+			err = genBookEpub(fileName, detail, module.Content)
+		} else {
+			fmt.Printf("【\033[31;1m%s\033[0m】无解读文稿\n", bookName)
+		}
+	case 7:
+		if articleFragmentId > 0 {
+			module, err1 := Instance.BookModuleContent(bookID, articleFragmentId)
+			if err1 != nil {
+				return err1
+			}
+			err = genDocx(fileName, bookName, detail.BookInfo.SpeakerName, module.Content)
+		} else {
+			fmt.Printf("【\033[31;1m%s\033[0m】无解读文稿\n", bookName)
+		}
+	case 8:
+		var article, mindmap string
+		if articleFragmentId > 0 {
+			module, err1 := Instance.BookModuleContent(bookID, articleFragmentId)
+			if err1 != nil {
+				return err1
+			}
+			article = module.Content
+		}
+		if thinkFragmentId > 0 {
+			module, err1 := Instance.BookModuleContent(bookID, thinkFragmentId)
+			if err1 != nil {
+				return err1
+			}
+			mindmap = module.Content
+		}
+		if article == "" && mindmap == "" {
+			fmt.Printf("【\033[31;1m%s\033[0m】无解读文稿\n", bookName)
+			break
+		}
+		err = genBookHtml(fileName, detail, article, mindmap)
+	case 9:
+		var article string
+		if articleFragmentId > 0 {
+			module, err1 := Instance.BookModuleContent(bookID, articleFragmentId)
+			if err1 != nil {
+				return err1
+			}
+			article = module.Content
+		}
+		err = genVaultNote(fileName, detail, businessType, article, opt)
+	case 10:
+		err = genDossierMd(fileName, detail, businessType, opt)
+	case 11:
+		err = genDossierHtml(fileName, detail, businessType, opt)
+	case 12:
+		err = genCommentsCsv(fileName, detail, opt)
 	}
 
 	return
 }
 
-// DownloadCourse 下载课程音频
-func DownloadCourse(courseID, downloadType int) (err error) {
+// DownloadCourse 下载课程音频、视频、Word 文稿或离线 HTML，downloadType 为 6 时生成整门课程的 EPUB
+func DownloadCourse(courseID, downloadType int, opt DownloadOptions) (err error) {
 	courseIDStr := utils.Int2String(courseID)
 
 	cParam := services.CourseInfoParam{
@@ -511,18 +837,7 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 	}
 
 	// 获取封面图
-	var coverBytes []byte
-	if titleImageUrl != "" {
-		cover, err1 := http.Get(titleImageUrl)
-		if err1 != nil {
-			return err1
-		}
-		defer cover.Body.Close()
-		coverBytes, err = io.ReadAll(cover.Body)
-		if err != nil {
-			return err
-		}
-	}
+	coverBytes, coverMime := fetchCover(titleImageUrl)
 
 	subDir := getSubDir(4)
 	fileSuffix := getFileSuffix(downloadType)
@@ -531,6 +846,14 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 		fmt.Println(err)
 		return err
 	}
+	if err := writeCourseManifest(filePath, courseID, albumName); err != nil {
+		fmt.Println(err)
+	}
+
+	if downloadType == 6 {
+		fileName := filepath.Join(filePath, utils.FileName(albumName, fileSuffix))
+		return genCourseEpub(fileName, courseID, detail, list, coverBytes)
+	}
 
 	// 统计总数和已完成数
 	totalItems := len(list)
@@ -538,33 +861,73 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 
 	// 先统计已存在的文件
 	for _, program := range list {
-		seq := program.Seq
-		if program.ChapterInfo != nil {
-			seq = utils.Int2String(program.ChapterInfo.ChapterSeq) + "-" + program.Seq
-		}
-		title := strings.TrimSpace(program.Title)
-		fileName := filepath.Join(filePath, utils.FileName(seq+"."+title, fileSuffix))
+		fileName := filepath.Join(filePath, utils.FileName(courseItemName(program), fileSuffix))
 		if utils.CheckFileExist(fileName) {
 			completedItems++
 		}
 	}
 
 	for _, program := range list {
-		seq := program.Seq
-		if program.ChapterInfo != nil {
-			seq = utils.Int2String(program.ChapterInfo.ChapterSeq) + "-" + program.Seq
-		}
 		title := strings.TrimSpace(program.Title)
-		fileName := filepath.Join(filePath, utils.FileName(seq+"."+title, fileSuffix))
+		fileName := filepath.Join(filePath, utils.FileName(courseItemName(program), fileSuffix))
 
 		if utils.CheckFileExist(fileName) {
 			fmt.Printf("【\033[37;1m%s\033[0m】已存在\n", fileName)
+			if downloadType == 1 {
+				if err := makeTempoCopy(fileName, program.Duration, opt); err != nil {
+					fmt.Printf("【\033[31;1m%s\033[0m】倍速副本生成失败: %v\n", title, err)
+				}
+			}
+			continue
+		}
+
+		if downloadType == 7 || downloadType == 8 {
+			var err error
+			if downloadType == 7 {
+				err = genProgramDocx(fileName, courseID, program, detail.Author)
+			} else {
+				err = genProgramHtml(fileName, courseID, detail, program)
+			}
+			if err != nil {
+				fmt.Printf("【\033[31;1m%s\033[0m】文稿下载失败: %v\n", title, err)
+				continue
+			}
+			completedItems++
+			SendCourseItemCompleted(courseIDStr, "course", albumName, title, completedItems, totalItems)
 			continue
 		}
 
-		var rawURL string
+		var rawURL, videoURL string
+		var loudness services.LoudnessNormalizationInfo
+		skipLoudness := false
 		if downloadType == 1 {
 			rawURL = program.AudioUrl
+			// 节目列表中没有响度信息，纯视频节目也没有音频链接，需要时从节目详情中获取
+			if opt.LoudnessTarget != 0 || rawURL == "" {
+				programDetail, err := Instance.ProgramDetail(services.ProgramDetailParam{
+					AlbumId:    courseID,
+					ProgramId:  program.Id,
+					FragmentId: program.FragmentId,
+				})
+				switch {
+				case err != nil && rawURL == "":
+					fmt.Printf("【\033[31;1m%s\033[0m】获取节目详情失败: %v\n", title, err)
+					continue
+				case err != nil:
+					// 响度信息是可选的，获取失败时按节目列表中的音频链接下载，不做响度标准化
+					fmt.Printf("【\033[33;1m%s\033[0m】获取节目详情失败，跳过响度标准化: %v\n", title, err)
+					skipLoudness = true
+				default:
+					loudness = programDetail.AudioLoudnessNormalizationInfo
+					if rawURL == "" {
+						rawURL = programDetail.AudioInfo.MediaUrl
+					}
+					if rawURL == "" && programDetail.VideoInfo.MediaUrl != "" {
+						videoURL = programDetail.VideoInfo.MediaUrl
+						loudness = programDetail.VideoLoudnessNormalizationInfo
+					}
+				}
+			}
 		} else if downloadType == 2 {
 			programDetail, err := Instance.ProgramDetail(services.ProgramDetailParam{
 				AlbumId:    courseID,
@@ -576,22 +939,32 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 				return err
 			}
 			rawURL = programDetail.VideoInfo.MediaUrl
+			loudness = programDetail.VideoLoudnessNormalizationInfo
 		}
 
-		if rawURL != "" {
+		if rawURL != "" || videoURL != "" {
 			// 获取文件的扩展名
 			ext, _ := utils.GetUrlExt(rawURL)
 			var downloadErr error
 
-			switch ext {
-			case ".mp3":
-				var opt utils.ID3Options
-				opt.Artist = detail.Author
-				opt.Title = title
-				opt.Album = albumName
-				opt.Cover = coverBytes
-				downloadErr = utils.DownloadAudio(fileName, rawURL, opt)
-			case ".m3u8":
+			var id3 utils.ID3Options
+			id3.Artist = detail.Author
+			id3.Title = title
+			id3.Album = albumName
+			id3.Cover = coverBytes
+			id3.CoverMime = coverMime
+
+			switch {
+			case videoURL != "":
+				// 纯视频节目，提取音轨后按音频节目写入标签
+				fmt.Printf("【\033[33;1m%s\033[0m】无音频，从视频中提取音轨\n", title)
+				downloadErr = utils.ExtractAudio(videoURL, fileName)
+				if downloadErr == nil {
+					downloadErr = utils.TagAudio(fileName, id3)
+				}
+			case ext == ".mp3":
+				downloadErr = utils.DownloadAudio(fileName, rawURL, id3)
+			case ext == ".m3u8":
 				downloadErr = utils.MergeAudioAndVideo([]string{rawURL}, fileName)
 				if downloadErr != nil {
 					fmt.Println(rawURL)
@@ -600,6 +973,12 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 			default:
 				fmt.Println(rawURL)
 			}
+			if downloadErr == nil && !skipLoudness && utils.CheckFileExist(fileName) {
+				downloadErr = normalizeLoudness(fileName, loudness, opt)
+			}
+			if downloadErr == nil && downloadType == 1 && utils.CheckFileExist(fileName) {
+				downloadErr = makeTempoCopy(fileName, program.Duration, opt)
+			}
 
 			// 如果单个文件下载成功，发送进度通知
 			if downloadErr == nil {
@@ -617,6 +996,57 @@ func DownloadCourse(courseID, downloadType int) (err error) {
 	return
 }
 
+// courseItemName 课程节目的文件名（不含扩展名），格式为 [章节序号-]节目序号.标题
+func courseItemName(program services.Program) string {
+	seq := program.Seq
+	if program.ChapterInfo != nil {
+		seq = utils.Int2String(program.ChapterInfo.ChapterSeq) + "-" + program.Seq
+	}
+	return seq + "." + strings.TrimSpace(program.Title)
+}
+
+// normalizeLoudness 按下载选项对音视频文件进行响度标准化
+func normalizeLoudness(fileName string, info services.LoudnessNormalizationInfo, opt DownloadOptions) error {
+	if opt.LoudnessTarget == 0 {
+		return nil
+	}
+	return utils.NormalizeLoudness(fileName, utils.LoudnessOptions{
+		Mode:      opt.LoudnessMode,
+		Target:    opt.LoudnessTarget,
+		LufsValue: info.LufsValue,
+		GainValue: info.GainValue,
+	})
+}
+
+// fetchCover 获取封面图，按实际类型识别 MIME，并按配置转换格式、缩小尺寸
+// 封面是可选的，获取或处理失败时只打印提示并返回空，不影响下载
+func fetchCover(coverUrl string) (data []byte, mimeType string) {
+	if coverUrl == "" {
+		return
+	}
+	data, err := utils.FetchBytes(coverUrl)
+	if err == nil {
+		data, mimeType, err = utils.PrepareCover(data, config.Conf.CoverMaxSize)
+	}
+	if err != nil {
+		fmt.Printf("【\033[31;1m%s\033[0m】封面获取失败，不嵌入封面: %v\n", coverUrl, err)
+		return nil, ""
+	}
+	return
+}
+
+// makeTempoCopy 按下载选项生成保持音调的倍速副本，副本已存在时跳过
+func makeTempoCopy(fileName string, duration int, opt DownloadOptions) error {
+	if opt.Tempo == 0 {
+		return nil
+	}
+	tempoFile := utils.TempoFileName(fileName, opt.Tempo)
+	if utils.CheckFileExist(tempoFile) {
+		return nil
+	}
+	return utils.ChangeTempo(fileName, tempoFile, opt.Tempo, duration)
+}
+
 // replaceLetterSpacing
 func replaceLetterSpacing(s *goquery.Selection) {
 	if style, exists := s.Attr("style"); exists {
@@ -659,11 +1089,18 @@ func getSubDir(bType int) string {
 
 func getFileSuffix(dType int) string {
 	list := map[int]string{
-		1: "mp3",
-		2: "mp4",
-		3: "md",
-		4: "pdf",
-		5: "jpeg",
+		1:  "mp3",
+		2:  "mp4",
+		3:  "md",
+		4:  "pdf",
+		5:  "jpeg",
+		6:  "epub",
+		7:  "docx",
+		8:  "html",
+		9:  "md",
+		10: "dossier.md",
+		11: "dossier.html",
+		12: "comments.csv",
 	}
 	return list[dType]
 }
@@ -77,16 +77,15 @@ type T4 struct {
 			ModuleName string `json:"moduleName"`
 		} `json:"articles"`
 		AudioInfo struct {
-			Duration                  int `json:"duration"`
-			FragmentId                int `json:"fragmentId"`
-			LoudnessNormalizationInfo struct {
-			} `json:"loudnessNormalizationInfo"`
-			MediaCoverUrl            string `json:"mediaCoverUrl"`
-			MediaFilesize            int    `json:"mediaFilesize"`
-			MediaUrl                 string `json:"mediaUrl"`
-			TrialCompletedButtonText string `json:"trialCompletedButtonText"`
-			TrialCompletedText       string `json:"trialCompletedText"`
-			TrialDuration            int    `json:"trialDuration"`
+			Duration                  int                       `json:"duration"`
+			FragmentId                int                       `json:"fragmentId"`
+			LoudnessNormalizationInfo LoudnessNormalizationInfo `json:"loudnessNormalizationInfo"`
+			MediaCoverUrl             string                    `json:"mediaCoverUrl"`
+			MediaFilesize             int                       `json:"mediaFilesize"`
+			MediaUrl                  string                    `json:"mediaUrl"`
+			TrialCompletedButtonText  string                    `json:"trialCompletedButtonText"`
+			TrialCompletedText        string                    `json:"trialCompletedText"`
+			TrialDuration             int                       `json:"trialDuration"`
 		} `json:"audioInfo"`
 		Authors []struct {
 			Name    string   `json:"name"`
@@ -107,16 +106,15 @@ type T4 struct {
 			} `json:"infos"`
 		} `json:"extract"`
 		VideoInfo struct {
-			Duration                  int `json:"duration"`
-			FragmentId                int `json:"fragmentId"`
-			LoudnessNormalizationInfo struct {
-			} `json:"loudnessNormalizationInfo"`
-			MediaCoverUrl            string `json:"mediaCoverUrl"`
-			MediaFilesize            int    `json:"mediaFilesize"`
-			MediaUrl                 string `json:"mediaUrl"`
-			TrialCompletedButtonText string `json:"trialCompletedButtonText"`
-			TrialCompletedText       string `json:"trialCompletedText"`
-			TrialDuration            int    `json:"trialDuration"`
+			Duration                  int                       `json:"duration"`
+			FragmentId                int                       `json:"fragmentId"`
+			LoudnessNormalizationInfo LoudnessNormalizationInfo `json:"loudnessNormalizationInfo"`
+			MediaCoverUrl             string                    `json:"mediaCoverUrl"`
+			MediaFilesize             int                       `json:"mediaFilesize"`
+			MediaUrl                  string                    `json:"mediaUrl"`
+			TrialCompletedButtonText  string                    `json:"trialCompletedButtonText"`
+			TrialCompletedText        string                    `json:"trialCompletedText"`
+			TrialDuration             int                       `json:"trialDuration"`
 		} `json:"videoInfo"`
 	} `json:"data"`
 	Msg        string `json:"msg"`
@@ -200,16 +198,15 @@ type Article struct {
 }
 
 type AudioInfo struct {
-	Duration                  int `json:"duration"`
-	FragmentId                int `json:"fragmentId"`
-	LoudnessNormalizationInfo struct {
-	} `json:"loudnessNormalizationInfo"`
-	MediaCoverUrl            string `json:"mediaCoverUrl"`
-	MediaFilesize            int    `json:"mediaFilesize"`
-	MediaUrl                 string `json:"mediaUrl"`
-	TrialCompletedButtonText string `json:"trialCompletedButtonText"`
-	TrialCompletedText       string `json:"trialCompletedText"`
-	TrialDuration            int    `json:"trialDuration"`
+	Duration                  int                       `json:"duration"`
+	FragmentId                int                       `json:"fragmentId"`
+	LoudnessNormalizationInfo LoudnessNormalizationInfo `json:"loudnessNormalizationInfo"` // 响度标准化信息
+	MediaCoverUrl             string                    `json:"mediaCoverUrl"`
+	MediaFilesize             int                       `json:"mediaFilesize"`
+	MediaUrl                  string                    `json:"mediaUrl"`
+	TrialCompletedButtonText  string                    `json:"trialCompletedButtonText"`
+	TrialCompletedText        string                    `json:"trialCompletedText"`
+	TrialDuration             int                       `json:"trialDuration"`
 }
 
 type Speaker struct {
@@ -1,5 +1,7 @@
 package services
 
+import "github.com/yann0917/fs-gui/utils"
+
 // Knowledge 课程
 type Knowledge struct {
 	Author         string `json:"author"`
@@ -14,6 +16,25 @@ type Knowledge struct {
 	WatermarkImage string `json:"watermarkImage"`
 }
 
+// Course 转换为 web 端课程列表的结构，Id 即课程详情和下载使用的课程ID
+func (k Knowledge) Course() Course {
+	return Course{
+		BizType:        k.BizType,
+		CourseId:       utils.String2Int(k.Id),
+		HasBuy:         k.HasBuy,
+		Introduct:      k.Introduct,
+		PicUrl:         k.PicUrl,
+		PlayCount:      k.PlayCount,
+		SpeakerName:    k.Author,
+		Title:          k.Title,
+		TotalPublishNo: k.TotalPublishNo,
+		WatermarkImage: k.WatermarkImage,
+	}
+}
+
+// MarketInfo 课程运营信息，字段随运营位变化，保留接口返回的原始结构
+type MarketInfo map[string]interface{}
+
 // CourseInfo 课程详情
 type CourseInfo struct {
 	ActualSaleScene []interface{} `json:"actualSaleScene"`
@@ -53,12 +53,12 @@ const (
 	// ApiProgramList app端课程下的节目列表
 	// ApiProgramList = "/smart-orch/program/v100/list"
 	// ApiGiftCardConfig 礼品卡配置
-	ApiGiftCardConfig = "fdtalk-orch/app/giftCard/v100/config"
+	ApiGiftCardConfig = "/fdtalk-orch/app/giftCard/v100/config"
 	// ApiUserGiftCards 我的礼品卡列表
 	ApiUserGiftCards = "/fdtalk-orch/app/giftCard/v100/userGiftCards"
 
 	// ApiGiftCardDetail 礼品卡详情
-	ApiGiftCardDetail = "fdtalk-orch/app/giftCard/v100/detail"
+	ApiGiftCardDetail = "/fdtalk-orch/app/giftCard/v100/detail"
 )
 
 type Response struct {
@@ -128,6 +128,38 @@ type CommentParam struct {
 	ObjectSourceValue string `json:"objectSourceValue,omitempty"`
 }
 
+// 评论列表的排序方式
+const (
+	CommentTabRecommend = 1 // 推荐
+	CommentTabHot       = 2 // 最热
+	CommentTabLatest    = 3 // 最新
+)
+
+// NewCommentParam 根据书籍详情生成评论列表参数，优先使用 bookComponent.compComment 中的参数
+func NewCommentParam(detail BookContent, tabType, pageNo, pageSize int) CommentParam {
+	var comp T
+	if detail.BookComponent.CompComment != "" {
+		_ = utils.UnmarshalJSON([]byte(detail.BookComponent.CompComment), &comp)
+	}
+	param := CommentParam{
+		ResourceId:    utils.Int2String(detail.BookInfo.BookId),
+		BizObjectCode: comp.BizObjectCode,
+		TabType:       tabType,
+		PageNo:        utils.Int2String(pageNo),
+		PageSize:      utils.Int2String(pageSize),
+	}
+	if comp.ResourceId > 0 {
+		param.ResourceId = utils.Int2String(comp.ResourceId)
+	}
+	if param.BizObjectCode == "" {
+		param.BizObjectCode = detail.BizObjectCode
+	}
+	if param.TabType == 0 {
+		param.TabType = comp.TabType
+	}
+	return param
+}
+
 type T struct {
 	BizObjectCode string `json:"bizObjectCode"`
 	ResourceId    int    `json:"resourceId"`
@@ -142,6 +174,18 @@ type KnowledgeListParam struct {
 	BookReadStatus int   `json:"bookReadStatus"`
 }
 
+// KnowledgeMarketInfoParam 按类型获取课程运营信息
+type KnowledgeMarketInfoParam struct {
+	Type int `json:"type"`
+}
+
+// GiftCardParam 礼品卡请求参数，获取详情时需指定 CardId
+type GiftCardParam struct {
+	Token  string `json:"token"`
+	CardId int64  `json:"cardId,omitempty"`
+	Code   string `json:"code,omitempty"`
+}
+
 // CourseListParam 课程列表请求参数
 type CourseListParam struct {
 	SortType     int        `json:"sortType"`     // 排序类型，例如：2-最热
@@ -378,6 +422,113 @@ func (s *Service) BookModuleContent(bookId, fragmentId int) (detail BookContent,
 	return
 }
 
+// KnowledgeMainList app端课程主列表
+func (s *Service) KnowledgeMainList(param KnowledgeListParam) (list []Knowledge, err error) {
+	cipher, err := handleEncryptParam(param)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiKnowledgeMainList)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &list)
+	return
+}
+
+// KnowledgeMarketInfo app端课程运营信息
+func (s *Service) KnowledgeMarketInfo(param KnowledgeMarketInfoParam) (info MarketInfo, err error) {
+	cipher, err := handleEncryptParam(param)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiKnowledgeMarketInfo)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &info)
+	return
+}
+
+// UserGiftCards 我的礼品卡列表
+func (s *Service) UserGiftCards() (cards UserGiftCards, err error) {
+	cipher, err := handleEncryptParam(GiftCardParam{Token: Token})
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiUserGiftCards)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &cards)
+	return
+}
+
+// GiftCardConfig 礼品卡配置
+func (s *Service) GiftCardConfig() (list []GiftCardConfig, err error) {
+	cipher, err := handleEncryptParam(GiftCardParam{Token: Token})
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiGiftCardConfig)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &list)
+	return
+}
+
+// GiftCardDetail 礼品卡详情，包含分享链接和祝福语
+func (s *Service) GiftCardDetail(cardId int64, code string) (detail GiftCardDetail, err error) {
+	param := GiftCardParam{
+		Token:  Token,
+		CardId: cardId,
+		Code:   code,
+	}
+	cipher, err := handleEncryptParam(param)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiGiftCardDetail)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &detail)
+	return
+}
+
+// BookComments 书籍评论列表
+func (s *Service) BookComments(param CommentParam) (list CommentList, err error) {
+	cipher, err := handleEncryptParam(param)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.R().
+		SetBody(cipher).
+		Post(ApiCommentList)
+	reader, err := handleHTTPResponse(resp, err)
+	if err != nil {
+		return
+	}
+	err = handleJSONParse(reader, &list)
+	return
+}
+
 func (s *Service) KnowledgeList(param KnowledgeListParam) (list []Knowledge, err error) {
 	cipher, err := handleEncryptParam(param)
 	if err != nil {
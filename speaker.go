@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// speakerSubDir 按讲者批量下载时的保存目录，每位讲者一个子目录
+const speakerSubDir = "讲者"
+
+// speakerCacheTTL 讲者缓存的有效期，过期后再次访问时重新获取
+const speakerCacheTTL = time.Hour
+
+// SpeakerInfo 讲者及其解读的书籍，由书籍详情中的 speakers 汇总而来
+type SpeakerInfo struct {
+	Id           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Summary      string                 `json:"summary"`
+	HeadImageUrl string                 `json:"headImageUrl"`
+	BookNum      int                    `json:"bookNum"` // 接口返回的解读书籍数量，可能多于 Books
+	ReadCount    int                    `json:"readCount"`
+	Books        []services.SpeakerBook `json:"books"`
+	UpdatedAt    int64                  `json:"updatedAt"`
+}
+
+// speakerStore 讲者缓存，浏览书籍详情和下载书籍时顺带记录书籍中的讲者
+type speakerStore struct {
+	speakers map[string]*SpeakerInfo
+	mutex    sync.RWMutex
+}
+
+var speakerCache = &speakerStore{
+	speakers: make(map[string]*SpeakerInfo),
+}
+
+// speakerID 讲者 id，接口未返回时使用讲者书籍中的 speakerId
+func speakerID(speaker services.Speaker) string {
+	if speaker.Id != "" {
+		return speaker.Id
+	}
+	for _, book := range speaker.SpeakerBooks {
+		if book.SpeakerId > 0 {
+			return utils.Int2String(book.SpeakerId)
+		}
+	}
+	return ""
+}
+
+// mergeSpeakerBook 按书籍 id 合并，已有书籍的位置不变
+func mergeSpeakerBook(books []services.SpeakerBook, book services.SpeakerBook) []services.SpeakerBook {
+	for i := range books {
+		if books[i].BookId == book.BookId {
+			if book.BookName == "" {
+				book.BookName = books[i].BookName
+			}
+			if book.CoverIcon == "" {
+				book.CoverIcon = books[i].CoverIcon
+			}
+			books[i] = book
+			return books
+		}
+	}
+	return append(books, book)
+}
+
+// record 记录书籍详情中的讲者及其书籍，当前书籍也计入其讲者的书籍
+func (ss *speakerStore) record(detail services.BookContent) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	book := detail.BookInfo
+	for _, speaker := range detail.Speakers {
+		id := speakerID(speaker)
+		if id == "" {
+			continue
+		}
+		info, ok := ss.speakers[id]
+		if !ok {
+			info = &SpeakerInfo{Id: id}
+			ss.speakers[id] = info
+		}
+		if speaker.Name != "" {
+			info.Name = strings.TrimSpace(speaker.Name)
+		}
+		if speaker.Summary != "" {
+			info.Summary = speaker.Summary
+		}
+		if speaker.HeadImageRawUrl != "" {
+			info.HeadImageUrl = speaker.HeadImageRawUrl
+		} else if speaker.HeaderImageUrl != "" {
+			info.HeadImageUrl = speaker.HeaderImageUrl
+		}
+		if speaker.BookNum > 0 {
+			info.BookNum = speaker.BookNum
+		}
+		if speaker.ReadCount > 0 {
+			info.ReadCount = speaker.ReadCount
+		}
+		for _, speakerBook := range speaker.SpeakerBooks {
+			if speakerBook.BookId > 0 {
+				info.Books = mergeSpeakerBook(info.Books, speakerBook)
+			}
+		}
+		if book.BookId > 0 && (len(detail.Speakers) == 1 || strings.TrimSpace(book.SpeakerName) == info.Name) {
+			speakerId, _ := strconv.Atoi(id)
+			info.Books = mergeSpeakerBook(info.Books, services.SpeakerBook{
+				BookId:    book.BookId,
+				BookName:  strings.TrimSpace(book.Title),
+				CoverIcon: book.CoverImg,
+				SpeakerId: speakerId,
+			})
+		}
+		info.UpdatedAt = time.Now().Unix()
+	}
+}
+
+// get 获取缓存的讲者，fresh 表示缓存未过期
+func (ss *speakerStore) get(id string) (info SpeakerInfo, ok, fresh bool) {
+	ss.mutex.RLock()
+	defer ss.mutex.RUnlock()
+
+	cached, ok := ss.speakers[id]
+	if !ok {
+		return
+	}
+	info = *cached
+	info.Books = append([]services.SpeakerBook{}, cached.Books...)
+	fresh = time.Since(time.Unix(info.UpdatedAt, 0)) < speakerCacheTTL
+	return
+}
+
+// getSpeaker 获取讲者及其书籍：缓存有效时直接返回，否则通过 bookId 或缓存中的书籍重新获取
+func getSpeaker(id string, bookID int, refresh bool) (info SpeakerInfo, err error) {
+	info, ok, fresh := speakerCache.get(id)
+	if ok && fresh && !refresh && bookID == 0 {
+		return
+	}
+
+	seed := bookID
+	if seed == 0 && ok && len(info.Books) > 0 {
+		seed = info.Books[0].BookId
+	}
+	if seed == 0 {
+		return info, fmt.Errorf("讲者 %s 不在缓存中，请通过 bookId 指定该讲者的任一书籍", id)
+	}
+	detail, err := Instance.BookContent(seed)
+	if err != nil {
+		if ok {
+			// 获取失败时仍返回过期的缓存
+			fmt.Printf("【\033[31;1m%s\033[0m】更新讲者失败: %v\n", info.Name, err)
+			return info, nil
+		}
+		return
+	}
+	speakerCache.record(detail)
+
+	if info, ok, _ = speakerCache.get(id); !ok {
+		return info, fmt.Errorf("书籍 %d 中没有讲者 %s", seed, id)
+	}
+	return
+}
+
+// speakerDir 讲者书籍的保存目录，相对于输出目录
+func speakerDir(name string) string {
+	return filepath.Join(speakerSubDir, utils.FileName(name, ""))
+}
+
+// DownloadSpeakerBooks 按指定的下载类型依次下载讲者的全部书籍，保存到输出目录下的 讲者/讲者名 目录
+// 笔记库笔记(类型 9)仍保存在笔记库中，以便 wikilink 和索引笔记正常使用
+func DownloadSpeakerBooks(info SpeakerInfo, downloadTypes []int, opt DownloadOptions) (err error) {
+	id := "speaker:" + info.Id
+	SendDownloadStarted(id, "speaker", info.Name)
+	defer func() {
+		if err != nil {
+			SendDownloadFailed(id, "speaker", info.Name, err.Error())
+		} else {
+			SendDownloadCompleted(id, "speaker", info.Name)
+		}
+	}()
+
+	opt.SubDir = speakerDir(info.Name)
+	failed := 0
+	for i, book := range info.Books {
+		for _, downloadType := range downloadTypes {
+			if err := Download(book.BookId, 0, downloadType, opt); err != nil {
+				failed++
+				fmt.Printf("【\033[31;1m%s\033[0m】下载失败: %v\n", book.BookName, err)
+			}
+		}
+		SendDownloadProgress(id, "speaker", info.Name, i+1, len(info.Books))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个文件下载失败", failed)
+	}
+	return
+}
+
+// parseDownloadTypes 解析以逗号分隔的下载类型，如 1,3,4
+func parseDownloadTypes(s string) (downloadTypes []int, err error) {
+	seen := make(map[int]bool)
+	for _, typeStr := range strings.Split(s, ",") {
+		if typeStr = strings.TrimSpace(typeStr); typeStr == "" {
+			continue
+		}
+		downloadType, err := strconv.Atoi(typeStr)
+		if err != nil || getFileSuffix(downloadType) == "" {
+			return nil, fmt.Errorf("不支持的下载类型: %s", typeStr)
+		}
+		if !seen[downloadType] {
+			seen[downloadType] = true
+			downloadTypes = append(downloadTypes, downloadType)
+		}
+	}
+	if len(downloadTypes) == 0 {
+		return nil, fmt.Errorf("请指定下载类型")
+	}
+	return
+}
+
+// handleGetSpeaker 讲者详情及其书籍，讲者不在缓存中时需通过 bookId 指定该讲者的任一书籍，refresh=true 时忽略缓存
+func handleGetSpeaker(c *gin.Context) {
+	bookId, _ := strconv.Atoi(c.Query("bookId"))
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+	info, err := getSpeaker(c.Param("id"), bookId, refresh)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	Success(c, info)
+}
+
+// handleDownloadSpeakerBooks 下载讲者的全部书籍，downloadTypes 为以逗号分隔的下载类型，其余参数同书籍下载
+func handleDownloadSpeakerBooks(c *gin.Context) {
+	downloadTypes, err := parseDownloadTypes(c.Query("downloadTypes"))
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	opt, err := parseDownloadOptions(c)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	bookId, _ := strconv.Atoi(c.Query("bookId"))
+	info, err := getSpeaker(c.Param("id"), bookId, false)
+	if err != nil {
+		Error(c, err)
+		return
+	}
+	if len(info.Books) == 0 {
+		Error(c, fmt.Errorf("讲者【%s】没有可下载的书籍", info.Name))
+		return
+	}
+	result := gin.H{
+		"speaker":       info.Name,
+		"total":         len(info.Books),
+		"downloadTypes": downloadTypes,
+		"dir":           filepath.Join(OutputDir, speakerDir(info.Name)),
+	}
+	for _, downloadType := range downloadTypes {
+		if downloadType == 9 {
+			result["notice"] = "笔记库笔记保存在笔记库中，不在讲者目录下"
+		}
+	}
+	go DownloadSpeakerBooks(info, downloadTypes, opt)
+	Success(c, result)
+}
@@ -0,0 +1,541 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	docxEmuPerPixel = 9525    // 96 DPI 下每像素对应的 EMU
+	docxMaxWidth    = 5486400 // 图片最大宽度 6 英寸(EMU)
+)
+
+// Docx Word 文档，内容由 HTML 转换为 OOXML 段落，标题、列表、引用使用 Word 内置样式
+type Docx struct {
+	Title   string
+	Creator string
+
+	body     strings.Builder
+	rels     []docxRel
+	images   []*docxImage
+	imageMap map[string]*docxImage // 图片地址 -> 图片
+	links    map[string]string     // 链接地址 -> 关系 id
+	nums     []int                 // 有序列表编号实例，每个有序列表重新从 1 开始编号
+	drawings int                   // 已插入的图片数量，用于生成唯一的图片 id
+}
+
+type docxRel struct {
+	id     string
+	typ    string
+	target string
+	mode   string
+}
+
+type docxImage struct {
+	relID  string
+	name   string
+	data   []byte
+	width  int // EMU
+	height int
+}
+
+// docxRun 一段样式相同的文本
+type docxRun struct {
+	text   string
+	bold   bool
+	italic bool
+	link   string
+	image  *docxImage
+	br     bool
+}
+
+// docxConverter HTML 转 OOXML 的转换状态
+type docxConverter struct {
+	doc    *Docx
+	runs   []docxRun
+	bold   int
+	italic int
+	link   string
+	pre    int
+	quote  int
+	lists  []int // 列表编号实例，无序列表为 1
+	inItem bool  // 当前段落是否为列表项的第一个段落
+}
+
+// NewDocx 创建 Word 文档
+func NewDocx(title string) *Docx {
+	return &Docx{
+		Title:    title,
+		imageMap: make(map[string]*docxImage),
+		links:    make(map[string]string),
+	}
+}
+
+// AddTitle 添加使用“标题”样式的段落
+func (d *Docx) AddTitle(text string) {
+	d.paragraph("Title", "", []docxRun{{text: text}})
+}
+
+// AddHtml 添加 HTML 内容，图片会被下载并嵌入文档
+func (d *Docx) AddHtml(content string) error {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	c := &docxConverter{doc: d}
+	c.walk(doc)
+	c.flush("")
+	return nil
+}
+
+func (c *docxConverter) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			c.walk(child)
+		}
+		return
+	}
+
+	switch n.Data {
+	case "script", "style", "head", "iframe", "noscript":
+		return
+	case "br":
+		c.runs = append(c.runs, docxRun{br: true})
+		return
+	case "img":
+		if img := c.doc.embedImage(n); img != nil {
+			c.runs = append(c.runs, docxRun{image: img})
+		}
+		return
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.flush("")
+		c.children(n)
+		c.flush("Heading" + n.Data[1:])
+		return
+	}
+
+	block := isDocxBlock(n.Data)
+	if block {
+		c.flush("")
+	}
+	switch n.Data {
+	case "strong", "b":
+		c.bold++
+		defer func() { c.bold-- }()
+	case "em", "i":
+		c.italic++
+		defer func() { c.italic-- }()
+	case "a":
+		link := c.link
+		if href := getAttr(n, "href"); strings.HasPrefix(href, "http") {
+			c.link = href
+		}
+		defer func() { c.link = link }()
+	case "pre":
+		c.pre++
+		defer func() { c.pre-- }()
+	case "blockquote":
+		c.quote++
+		defer func() { c.quote-- }()
+	case "ul":
+		c.lists = append(c.lists, 1)
+		defer func() { c.lists = c.lists[:len(c.lists)-1] }()
+	case "ol":
+		c.lists = append(c.lists, c.doc.newNumbering())
+		defer func() { c.lists = c.lists[:len(c.lists)-1] }()
+	case "li":
+		c.inItem = true
+	case "td", "th":
+		if len(c.runs) > 0 {
+			c.runs = append(c.runs, docxRun{text: "\t"})
+		}
+	}
+	c.children(n)
+	if block {
+		c.flush("")
+	}
+}
+
+func (c *docxConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.walk(child)
+	}
+}
+
+func isDocxBlock(tag string) bool {
+	switch tag {
+	case "p", "div", "section", "article", "blockquote", "ul", "ol", "li", "pre", "table", "tr", "figure", "figcaption":
+		return true
+	}
+	return false
+}
+
+// text 添加文本，pre 之外的连续空白合并为一个空格
+func (c *docxConverter) text(s string) {
+	if c.pre > 0 {
+		for i, line := range strings.Split(s, "\n") {
+			if i > 0 {
+				c.runs = append(c.runs, docxRun{br: true})
+			}
+			if line != "" {
+				c.runs = append(c.runs, docxRun{text: line})
+			}
+		}
+		return
+	}
+	// 保留与前后文本之间的空格，段首的空格在输出时去除
+	leading := strings.TrimLeftFunc(s, unicode.IsSpace) != s
+	trailing := strings.TrimRightFunc(s, unicode.IsSpace) != s
+	s = strings.Join(strings.Fields(s), " ")
+	if s == "" {
+		if leading && len(c.runs) > 0 {
+			c.runs = append(c.runs, docxRun{text: " "})
+		}
+		return
+	}
+	if leading {
+		s = " " + s
+	}
+	if trailing {
+		s += " "
+	}
+	c.runs = append(c.runs, docxRun{
+		text:   s,
+		bold:   c.bold > 0,
+		italic: c.italic > 0,
+		link:   c.link,
+	})
+}
+
+// flush 将已收集的文本输出为一个段落，style 为空时根据所在的列表或引用确定样式
+func (c *docxConverter) flush(style string) {
+	runs := c.runs
+	c.runs = nil
+	if len(runs) == 0 {
+		return
+	}
+	numPr := ""
+	if style == "" {
+		switch {
+		case len(c.lists) > 0:
+			style = "ListParagraph"
+			if c.inItem {
+				numPr = fmt.Sprintf(`<w:numPr><w:ilvl w:val="%d"/><w:numId w:val="%d"/></w:numPr>`, min(len(c.lists)-1, 8), c.lists[len(c.lists)-1])
+			} else {
+				numPr = fmt.Sprintf(`<w:ind w:left="%d"/>`, 720*len(c.lists))
+			}
+		case c.quote > 0:
+			style = "Quote"
+		}
+	}
+	c.inItem = false
+	c.doc.paragraph(style, numPr, runs)
+}
+
+// paragraph 写入段落
+func (d *Docx) paragraph(style, props string, runs []docxRun) {
+	d.body.WriteString("<w:p>")
+	if style != "" || props != "" {
+		d.body.WriteString("<w:pPr>")
+		if style != "" {
+			d.body.WriteString(`<w:pStyle w:val="` + style + `"/>`)
+		}
+		d.body.WriteString(props)
+		d.body.WriteString("</w:pPr>")
+	}
+	for i, run := range runs {
+		if i == 0 {
+			run.text = strings.TrimLeft(run.text, " ")
+		}
+		d.run(run)
+	}
+	d.body.WriteString("</w:p>\n")
+}
+
+func (d *Docx) run(run docxRun) {
+	if run.link != "" {
+		d.body.WriteString(`<w:hyperlink r:id="` + d.linkRel(run.link) + `">`)
+		defer d.body.WriteString("</w:hyperlink>")
+	}
+	d.body.WriteString("<w:r>")
+	var rPr strings.Builder
+	if run.link != "" {
+		rPr.WriteString(`<w:rStyle w:val="Hyperlink"/>`)
+	}
+	if run.bold {
+		rPr.WriteString("<w:b/>")
+	}
+	if run.italic {
+		rPr.WriteString("<w:i/>")
+	}
+	if rPr.Len() > 0 {
+		d.body.WriteString("<w:rPr>" + rPr.String() + "</w:rPr>")
+	}
+	switch {
+	case run.br:
+		d.body.WriteString("<w:br/>")
+	case run.image != nil:
+		d.drawings++
+		d.body.WriteString(run.image.drawing(d.drawings))
+	case run.text != "":
+		d.body.WriteString(`<w:t xml:space="preserve">` + xmlEscape(run.text) + "</w:t>")
+	}
+	d.body.WriteString("</w:r>")
+}
+
+// linkRel 外部链接的关系 id
+func (d *Docx) linkRel(link string) string {
+	if id, ok := d.links[link]; ok {
+		return id
+	}
+	id := fmt.Sprintf("rId%d", len(d.rels)+100)
+	d.rels = append(d.rels, docxRel{id: id, typ: docxRelHyperlink, target: link, mode: "External"})
+	d.links[link] = id
+	return id
+}
+
+// newNumbering 新建一个从 1 开始的有序列表编号实例，返回 numId
+func (d *Docx) newNumbering() int {
+	d.nums = append(d.nums, len(d.nums)+2)
+	return d.nums[len(d.nums)-1]
+}
+
+// embedImage 下载图片并嵌入文档，WebP、BMP 转为 JPEG，失败时返回 nil
+func (d *Docx) embedImage(n *html.Node) *docxImage {
+	src := getAttr(n, "data-src")
+	if src == "" {
+		src = getAttr(n, "src")
+	}
+	if src == "" {
+		return nil
+	}
+	if img, ok := d.imageMap[src]; ok {
+		return img
+	}
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(src, "file://") {
+		data, err = os.ReadFile(strings.TrimPrefix(src, "file://"))
+	} else {
+		data, err = FetchBytes(src)
+	}
+	if err == nil {
+		data, _, err = PrepareCover(data, 0)
+	}
+	var cfg image.Config
+	if err == nil {
+		cfg, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败: %v\n", src, err)
+		d.imageMap[src] = nil
+		return nil
+	}
+
+	_, ext := SniffImage(data)
+	img := &docxImage{
+		relID:  fmt.Sprintf("rId%d", len(d.rels)+100),
+		name:   fmt.Sprintf("image%d.%s", len(d.images)+1, ext),
+		data:   data,
+		width:  cfg.Width * docxEmuPerPixel,
+		height: cfg.Height * docxEmuPerPixel,
+	}
+	if img.width > docxMaxWidth {
+		img.height = img.height * docxMaxWidth / img.width
+		img.width = docxMaxWidth
+	}
+	d.rels = append(d.rels, docxRel{id: img.relID, typ: docxRelImage, target: "media/" + img.name})
+	d.images = append(d.images, img)
+	d.imageMap[src] = img
+	return img
+}
+
+// drawing 内嵌图片的 DrawingML
+func (img *docxImage) drawing(id int) string {
+	return fmt.Sprintf(`<w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%[1]d" cy="%[2]d"/><wp:docPr id="%[3]d" name="Picture %[3]d"/><wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture"><pic:pic><pic:nvPicPr><pic:cNvPr id="%[3]d" name="%[4]s"/><pic:cNvPicPr/></pic:nvPicPr><pic:blipFill><a:blip r:embed="%[5]s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill><pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%[1]d" cy="%[2]d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr></pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing>`,
+		img.width, img.height, id, img.name, img.relID)
+}
+
+// Write 生成 DOCX 文件
+func (d *Docx) Write(fileName string) error {
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err := d.write(fileName); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
+
+func (d *Docx) write(fileName string) error {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	files := []epubFile{
+		{"[Content_Types].xml", []byte(docxContentTypes)},
+		{"_rels/.rels", []byte(docxRootRels)},
+		{"docProps/core.xml", []byte(d.core())},
+		{"docProps/app.xml", []byte(docxApp)},
+		{"word/document.xml", []byte(docxDocumentHead + d.body.String() + docxDocumentFoot)},
+		{"word/styles.xml", []byte(docxStyles)},
+		{"word/numbering.xml", []byte(d.numbering())},
+		{"word/_rels/document.xml.rels", []byte(d.documentRels())},
+	}
+	for _, img := range d.images {
+		files = append(files, epubFile{"word/media/" + img.name, img.data})
+	}
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(f.content); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, buf.Bytes(), 0644)
+}
+
+func (d *Docx) core() string {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05Z")
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <dc:title>%s</dc:title>
+  <dc:creator>%s</dc:creator>
+  <dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>
+  <dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified>
+</cp:coreProperties>
+`, xmlEscape(d.Title), xmlEscape(d.Creator), now, now)
+}
+
+func (d *Docx) documentRels() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/numbering" Target="numbering.xml"/>
+`)
+	for _, rel := range d.rels {
+		mode := ""
+		if rel.mode != "" {
+			mode = ` TargetMode="` + rel.mode + `"`
+		}
+		sb.WriteString(fmt.Sprintf("  <Relationship Id=\"%s\" Type=\"%s\" Target=\"%s\"%s/>\n", rel.id, rel.typ, xmlEscape(rel.target), mode))
+	}
+	sb.WriteString("</Relationships>\n")
+	return sb.String()
+}
+
+// numbering 编号定义：numId 1 为项目符号，其余为各有序列表的编号实例
+func (d *Docx) numbering() string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:numbering xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+`)
+	bullets := []string{"•", "◦", "▪"}
+	for abstractID, format := range []string{"bullet", "decimal"} {
+		sb.WriteString(fmt.Sprintf("  <w:abstractNum w:abstractNumId=\"%d\"><w:multiLevelType w:val=\"hybridMultilevel\"/>", abstractID))
+		for lvl := 0; lvl < 9; lvl++ {
+			text := bullets[lvl%len(bullets)]
+			if format == "decimal" {
+				text = fmt.Sprintf("%%%d.", lvl+1)
+			}
+			sb.WriteString(fmt.Sprintf(`<w:lvl w:ilvl="%d"><w:start w:val="1"/><w:numFmt w:val="%s"/><w:lvlText w:val="%s"/><w:lvlJc w:val="left"/><w:pPr><w:ind w:left="%d" w:hanging="360"/></w:pPr></w:lvl>`,
+				lvl, format, text, 720*(lvl+1)))
+		}
+		sb.WriteString("</w:abstractNum>\n")
+	}
+	sb.WriteString(`  <w:num w:numId="1"><w:abstractNumId w:val="0"/></w:num>` + "\n")
+	// 嵌套的有序列表按层级使用 ilvl，每一级都需要重新从 1 开始编号
+	var overrides strings.Builder
+	for lvl := 0; lvl < 9; lvl++ {
+		overrides.WriteString(fmt.Sprintf(`<w:lvlOverride w:ilvl="%d"><w:startOverride w:val="1"/></w:lvlOverride>`, lvl))
+	}
+	for _, numID := range d.nums {
+		sb.WriteString(fmt.Sprintf(`  <w:num w:numId="%d"><w:abstractNumId w:val="1"/>%s</w:num>`+"\n", numID, overrides.String()))
+	}
+	sb.WriteString("</w:numbering>\n")
+	return sb.String()
+}
+
+const (
+	docxRelImage     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+	docxRelHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+  <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+  <Default Extension="xml" ContentType="application/xml"/>
+  <Default Extension="jpeg" ContentType="image/jpeg"/>
+  <Default Extension="png" ContentType="image/png"/>
+  <Default Extension="gif" ContentType="image/gif"/>
+  <Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+  <Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+  <Override PartName="/word/numbering.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.numbering+xml"/>
+  <Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+  <Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>
+</Types>
+`
+
+const docxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+  <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+  <Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+  <Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties" Target="docProps/app.xml"/>
+</Relationships>
+`
+
+const docxApp = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">
+  <Application>fs-gui</Application>
+</Properties>
+`
+
+const docxDocumentHead = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture">
+<w:body>
+`
+
+const docxDocumentFoot = `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440" w:header="720" w:footer="720" w:gutter="0"/></w:sectPr>
+</w:body>
+</w:document>
+`
+
+// docxStyles 样式表：标题带大纲级别，可在 Word、WPS 的导航窗格中显示
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:docDefaults>
+    <w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:eastAsia="宋体"/><w:sz w:val="22"/><w:lang w:val="en-US" w:eastAsia="zh-CN"/></w:rPr></w:rPrDefault>
+    <w:pPrDefault><w:pPr><w:spacing w:after="120" w:line="360" w:lineRule="auto"/></w:pPr></w:pPrDefault>
+  </w:docDefaults>
+  <w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+  <w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:jc w:val="center"/><w:spacing w:before="240" w:after="240"/></w:pPr><w:rPr><w:rFonts w:eastAsia="黑体"/><w:b/><w:sz w:val="44"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="360" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:rFonts w:eastAsia="黑体"/><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:rFonts w:eastAsia="黑体"/><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading4"><w:name w:val="heading 4"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="3"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading5"><w:name w:val="heading 5"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="4"/></w:pPr><w:rPr><w:b/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Heading6"><w:name w:val="heading 6"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:keepNext/><w:outlineLvl w:val="5"/></w:pPr><w:rPr><w:b/><w:i/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="Quote"><w:name w:val="Quote"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720" w:right="720"/><w:pBdr><w:left w:val="single" w:sz="12" w:space="8" w:color="CCCCCC"/></w:pBdr></w:pPr><w:rPr><w:i/><w:color w:val="666666"/></w:rPr></w:style>
+  <w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/><w:pPr><w:ind w:left="720"/><w:contextualSpacing/></w:pPr></w:style>
+  <w:style w:type="character" w:styleId="Hyperlink"><w:name w:val="Hyperlink"/><w:rPr><w:color w:val="0563C1"/><w:u w:val="single"/></w:rPr></w:style>
+</w:styles>
+`
@@ -3,7 +3,6 @@ package utils
 import (
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
 
@@ -17,6 +16,8 @@ type ID3Options struct {
 	Cover  []byte // 封面
 	Year   string
 	Genre  string // 流派
+
+	CoverMime string // 封面的 MIME 类型，为空时根据内容识别
 }
 
 // DownloadAudio 下载mp3文件
@@ -47,10 +48,24 @@ func DownloadAudio(title, fileUrl string, opt ID3Options) error {
 		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
 		return err
 	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	if err = TagAudio(title, opt); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
 
-	tag, err := id3v2.ParseReader(out, id3v2.Options{Parse: true})
+// TagAudio 写入mp3文件的ID3标签
+func TagAudio(fileName string, opt ID3Options) error {
+	tag, err := id3v2.Open(fileName, id3v2.Options{Parse: true})
 	if err != nil {
-		log.Fatal("Error while opening mp3 file: ", err)
+		return fmt.Errorf("打开mp3文件失败: %v", err)
 	}
 	defer tag.Close()
 	tag.DeleteAllFrames()
@@ -60,9 +75,13 @@ func DownloadAudio(title, fileUrl string, opt ID3Options) error {
 	tag.SetAlbum(opt.Album)
 
 	if len(opt.Cover) > 0 {
+		mimeType := opt.CoverMime
+		if mimeType == "" {
+			mimeType, _ = SniffImage(opt.Cover)
+		}
 		pic := id3v2.PictureFrame{
 			Encoding:    id3v2.EncodingUTF8,
-			MimeType:    "image/jpeg",
+			MimeType:    mimeType,
 			Picture:     opt.Cover,
 			Description: "Cover",
 			PictureType: id3v2.PTFrontCover,
@@ -81,10 +100,8 @@ func DownloadAudio(title, fileUrl string, opt ID3Options) error {
 
 	// Write tag to file.
 	if err = tag.Save(); err != nil {
-		log.Fatal("Error while saving a tag: ", err)
+		return fmt.Errorf("写入ID3标签失败: %v", err)
 	}
-
-	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
 	return nil
 }
 
@@ -116,11 +133,24 @@ func Download(title, fileUrl string) error {
 	return nil
 }
 
+// FetchBytes 获取远程文件内容
+func FetchBytes(fileUrl string) ([]byte, error) {
+	resp, err := http.Get(fileUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求 %s 失败: %s", fileUrl, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
 // SaveFile 保存文件
 func SaveFile(title, content string) error {
 
 	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", title)
-	f, err := os.OpenFile(title, os.O_CREATE|os.O_WRONLY, 0644)
+	f, err := os.OpenFile(title, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 	if err != nil {
 		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
 		return err
@@ -0,0 +1,494 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const epubCss = `body { font-family: "PingFang SC", "Microsoft YaHei", "Noto Sans CJK SC", sans-serif; line-height: 1.8; color: #333; }
+h1 { font-size: 1.6em; text-align: center; margin: 1em 0; }
+h2 { font-size: 1.3em; margin: 1.2em 0 0.6em; }
+h3 { font-size: 1.1em; margin: 1em 0 0.5em; }
+p { text-indent: 0; margin: 0.6em 0; }
+img { max-width: 100%; height: auto; }
+blockquote { margin: 1em 1.5em; padding-left: 0.8em; border-left: 3px solid #ccc; color: #666; }
+em { font-style: normal; color: #ff6002; }
+.cover { text-align: center; margin: 0; padding: 0; }
+.cover img { max-height: 100%; }
+.meta { color: #888; text-align: center; }
+`
+
+// Epub EPUB 3 电子书，章节内容为 HTML 片段，写入时转换为 XHTML
+type Epub struct {
+	Title        string
+	Authors      []string // 作者
+	Contributors []string // 讲者等其他贡献者
+	Language     string   // 默认 zh-CN
+	Identifier   string   // 唯一标识，为空时根据标题生成
+	Description  string
+	Publisher    string
+	Date         string // 发布日期，格式 2006-01-02
+	Subjects     []string
+
+	cover    *epubResource
+	chapters []*EpubChapter // 顶层章节
+	spine    []*EpubChapter // 按阅读顺序排列的所有章节
+	images   []*epubResource
+	imageMap map[string]*epubResource // 图片地址 -> 资源
+}
+
+// EpubChapter EPUB 章节
+type EpubChapter struct {
+	Title string
+
+	id       string
+	body     string       // 已转换的 XHTML 片段
+	headings []epubAnchor // 章节内的标题，用于生成目录
+	children []*EpubChapter
+}
+
+type epubAnchor struct {
+	level int
+	id    string
+	title string
+}
+
+type epubFile struct {
+	name    string
+	content []byte
+}
+
+type epubResource struct {
+	id        string
+	href      string
+	mediaType string
+	data      []byte
+}
+
+// NewEpub 创建 EPUB 电子书
+func NewEpub(title string) *Epub {
+	return &Epub{
+		Title:    title,
+		Language: "zh-CN",
+		imageMap: make(map[string]*epubResource),
+	}
+}
+
+// SetCover 设置封面图，WebP 等格式转为 JPEG
+func (e *Epub) SetCover(data []byte) error {
+	data, mimeType, err := PrepareCover(data, 0)
+	if err != nil {
+		return err
+	}
+	_, ext := SniffImage(data)
+	e.cover = &epubResource{
+		id:        "cover-image",
+		href:      "images/cover." + ext,
+		mediaType: mimeType,
+		data:      data,
+	}
+	return nil
+}
+
+// AddChapter 添加章节，content 为 HTML 片段
+// 图片会被下载并打包进电子书，h1~h3 标题会生成章节内的目录
+func (e *Epub) AddChapter(title, content string) (*EpubChapter, error) {
+	return e.AddSubChapter(nil, title, content)
+}
+
+// AddSubChapter 在 parent 下添加子章节，parent 为 nil 时添加顶层章节
+func (e *Epub) AddSubChapter(parent *EpubChapter, title, content string) (*EpubChapter, error) {
+	chapter := &EpubChapter{
+		Title: title,
+		id:    fmt.Sprintf("chapter_%03d", len(e.spine)+1),
+	}
+	body, err := e.convertContent(chapter, content)
+	if err != nil {
+		return nil, err
+	}
+	chapter.body = body
+
+	// 子章节紧跟在父章节及其已有的子章节之后
+	pos := len(e.spine)
+	if parent != nil {
+		last := parent
+		for len(last.children) > 0 {
+			last = last.children[len(last.children)-1]
+		}
+		for i, c := range e.spine {
+			if c == last {
+				pos = i + 1
+			}
+		}
+		parent.children = append(parent.children, chapter)
+	} else {
+		e.chapters = append(e.chapters, chapter)
+	}
+	e.spine = append(e.spine[:pos], append([]*EpubChapter{chapter}, e.spine[pos:]...)...)
+	return chapter, nil
+}
+
+// convertContent 解析 HTML 片段：下载图片、为标题添加锚点，并序列化为 XHTML
+func (e *Epub) convertContent(chapter *EpubChapter, content string) (string, error) {
+	context := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(content), context)
+	if err != nil {
+		return "", err
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; {
+			next := c.NextSibling
+			if c.Type == html.ElementNode {
+				switch c.DataAtom {
+				case atom.Script, atom.Style, atom.Iframe:
+					n.RemoveChild(c)
+					c = next
+					continue
+				case atom.Img:
+					if !e.embedImage(c) {
+						n.RemoveChild(c)
+						c = next
+						continue
+					}
+				case atom.H1, atom.H2, atom.H3:
+					anchor := epubAnchor{
+						level: int(c.Data[1] - '0'),
+						id:    fmt.Sprintf("%s_h%d", chapter.id, len(chapter.headings)+1),
+						title: strings.TrimSpace(nodeText(c)),
+					}
+					if anchor.title != "" {
+						setAttr(c, "id", anchor.id)
+						chapter.headings = append(chapter.headings, anchor)
+					}
+				}
+			}
+			walk(c)
+			c = next
+		}
+	}
+
+	root := &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div}
+	for _, node := range nodes {
+		root.AppendChild(node)
+	}
+	walk(root)
+
+	var buf bytes.Buffer
+	for c := root.FirstChild; c != nil; c = c.NextSibling {
+		if err = html.Render(&buf, c); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// embedImage 下载图片并改写为包内地址，失败时返回 false
+func (e *Epub) embedImage(n *html.Node) bool {
+	src := getAttr(n, "data-src")
+	if src == "" {
+		src = getAttr(n, "src")
+	}
+	if src == "" {
+		return false
+	}
+	res, ok := e.imageMap[src]
+	if !ok {
+		data, err := FetchBytes(src)
+		if err == nil {
+			data, err = ConvertWebp(data)
+		}
+		if err != nil {
+			fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败: %v\n", src, err)
+			return false
+		}
+		mimeType, ext := SniffImage(data)
+		id := fmt.Sprintf("img_%03d", len(e.images)+1)
+		res = &epubResource{id: id, href: "images/" + id + "." + ext, mediaType: mimeType, data: data}
+		e.images = append(e.images, res)
+		e.imageMap[src] = res
+	}
+
+	alt := getAttr(n, "alt")
+	n.Attr = nil
+	setAttr(n, "src", "../"+res.href)
+	setAttr(n, "alt", alt)
+	return true
+}
+
+// Write 生成 EPUB 文件
+func (e *Epub) Write(fileName string) error {
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err := e.write(fileName); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
+
+func (e *Epub) write(fileName string) error {
+	if e.Identifier == "" {
+		e.Identifier = "urn:md5:" + MD5str(e.Title)
+	}
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	// mimetype 必须是第一个文件且不压缩
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write([]byte("application/epub+zip")); err != nil {
+		return err
+	}
+
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", []byte(e.opf())},
+		{"OEBPS/nav.xhtml", []byte(e.nav())},
+		{"OEBPS/toc.ncx", []byte(e.ncx())},
+		{"OEBPS/style.css", []byte(epubCss)},
+	}
+	if e.cover != nil {
+		cover := fmt.Sprintf(`<div class="cover"><img src="%s" alt="%s"/></div>`, e.cover.href, xmlEscape(e.Title))
+		files = append(files,
+			epubFile{"OEBPS/cover.xhtml", []byte(e.xhtml(e.Title, cover, ""))},
+			epubFile{"OEBPS/" + e.cover.href, e.cover.data},
+		)
+	}
+	for _, chapter := range e.spine {
+		body := "<h1>" + xmlEscape(chapter.Title) + "</h1>\n" + chapter.body
+		files = append(files, epubFile{"OEBPS/text/" + chapter.id + ".xhtml", []byte(e.xhtml(chapter.Title, body, "../"))})
+	}
+	for _, img := range e.images {
+		files = append(files, epubFile{"OEBPS/" + img.href, img.data})
+	}
+
+	for _, f := range files {
+		w, err = zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(f.content); err != nil {
+			return err
+		}
+	}
+	if err = zw.Close(); err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, buf.Bytes(), 0644)
+}
+
+const epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func (e *Epub) xhtml(title, body, prefix string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" xml:lang="%[1]s" lang="%[1]s">
+<head>
+  <meta charset="UTF-8"/>
+  <title>%[2]s</title>
+  <link rel="stylesheet" type="text/css" href="%[3]sstyle.css"/>
+</head>
+<body>
+%[4]s
+</body>
+</html>
+`, e.Language, xmlEscape(title), prefix, body)
+}
+
+func (e *Epub) opf() string {
+	var meta, manifest, spine strings.Builder
+	meta.WriteString(fmt.Sprintf("    <dc:identifier id=\"bookid\">%s</dc:identifier>\n", xmlEscape(e.Identifier)))
+	meta.WriteString(fmt.Sprintf("    <dc:title>%s</dc:title>\n", xmlEscape(e.Title)))
+	meta.WriteString(fmt.Sprintf("    <dc:language>%s</dc:language>\n", e.Language))
+	for _, author := range e.Authors {
+		meta.WriteString(fmt.Sprintf("    <dc:creator>%s</dc:creator>\n", xmlEscape(author)))
+	}
+	for _, contributor := range e.Contributors {
+		meta.WriteString(fmt.Sprintf("    <dc:contributor>%s</dc:contributor>\n", xmlEscape(contributor)))
+	}
+	if e.Description != "" {
+		meta.WriteString(fmt.Sprintf("    <dc:description>%s</dc:description>\n", xmlEscape(e.Description)))
+	}
+	if e.Publisher != "" {
+		meta.WriteString(fmt.Sprintf("    <dc:publisher>%s</dc:publisher>\n", xmlEscape(e.Publisher)))
+	}
+	if e.Date != "" {
+		meta.WriteString(fmt.Sprintf("    <dc:date>%s</dc:date>\n", e.Date))
+	}
+	for _, subject := range e.Subjects {
+		meta.WriteString(fmt.Sprintf("    <dc:subject>%s</dc:subject>\n", xmlEscape(subject)))
+	}
+	meta.WriteString(fmt.Sprintf("    <meta property=\"dcterms:modified\">%s</meta>\n", time.Now().UTC().Format("2006-01-02T15:04:05Z")))
+
+	manifest.WriteString("    <item id=\"nav\" href=\"nav.xhtml\" media-type=\"application/xhtml+xml\" properties=\"nav\"/>\n")
+	manifest.WriteString("    <item id=\"ncx\" href=\"toc.ncx\" media-type=\"application/x-dtbncx+xml\"/>\n")
+	manifest.WriteString("    <item id=\"css\" href=\"style.css\" media-type=\"text/css\"/>\n")
+	if e.cover != nil {
+		meta.WriteString("    <meta name=\"cover\" content=\"cover-image\"/>\n")
+		manifest.WriteString(fmt.Sprintf("    <item id=\"%s\" href=\"%s\" media-type=\"%s\" properties=\"cover-image\"/>\n", e.cover.id, e.cover.href, e.cover.mediaType))
+		manifest.WriteString("    <item id=\"cover\" href=\"cover.xhtml\" media-type=\"application/xhtml+xml\"/>\n")
+		spine.WriteString("    <itemref idref=\"cover\"/>\n")
+	}
+	spine.WriteString("    <itemref idref=\"nav\"/>\n")
+	for _, chapter := range e.spine {
+		manifest.WriteString(fmt.Sprintf("    <item id=\"%s\" href=\"text/%s.xhtml\" media-type=\"application/xhtml+xml\"/>\n", chapter.id, chapter.id))
+		spine.WriteString(fmt.Sprintf("    <itemref idref=\"%s\"/>\n", chapter.id))
+	}
+	for _, img := range e.images {
+		manifest.WriteString(fmt.Sprintf("    <item id=\"%s\" href=\"%s\" media-type=\"%s\"/>\n", img.id, img.href, img.mediaType))
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="bookid" xml:lang="%s">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+%s  </metadata>
+  <manifest>
+%s  </manifest>
+  <spine toc="ncx">
+%s  </spine>
+</package>
+`, e.Language, meta.String(), manifest.String(), spine.String())
+}
+
+// navPoint 目录项，章节下挂章节内的标题
+type navPoint struct {
+	title    string
+	href     string
+	children []*navPoint
+}
+
+func (e *Epub) navPoints() []*navPoint {
+	return chapterNavPoints(e.chapters)
+}
+
+// chapterNavPoints 生成章节目录：先列出章节内的标题，再列出子章节
+func chapterNavPoints(chapters []*EpubChapter) []*navPoint {
+	var points []*navPoint
+	for _, chapter := range chapters {
+		href := "text/" + chapter.id + ".xhtml"
+		point := &navPoint{title: chapter.Title, href: href}
+		// 按标题级别嵌套，使用栈记录各级最近的目录项
+		stack := []struct {
+			level int
+			point *navPoint
+		}{{0, point}}
+		for _, h := range chapter.headings {
+			for len(stack) > 1 && stack[len(stack)-1].level >= h.level {
+				stack = stack[:len(stack)-1]
+			}
+			child := &navPoint{title: h.title, href: href + "#" + h.id}
+			parent := stack[len(stack)-1].point
+			parent.children = append(parent.children, child)
+			stack = append(stack, struct {
+				level int
+				point *navPoint
+			}{h.level, child})
+		}
+		point.children = append(point.children, chapterNavPoints(chapter.children)...)
+		points = append(points, point)
+	}
+	return points
+}
+
+func (e *Epub) nav() string {
+	var sb strings.Builder
+	var write func(points []*navPoint, indent string)
+	write = func(points []*navPoint, indent string) {
+		sb.WriteString(indent + "<ol>\n")
+		for _, p := range points {
+			sb.WriteString(fmt.Sprintf("%s  <li><a href=\"%s\">%s</a>", indent, p.href, xmlEscape(p.title)))
+			if len(p.children) > 0 {
+				sb.WriteString("\n")
+				write(p.children, indent+"    ")
+				sb.WriteString(indent + "  ")
+			}
+			sb.WriteString("</li>\n")
+		}
+		sb.WriteString(indent + "</ol>\n")
+	}
+	write(e.navPoints(), "    ")
+	body := "  <nav epub:type=\"toc\" id=\"toc\">\n    <h1>目录</h1>\n" + sb.String() + "  </nav>"
+	return e.xhtml("目录", body, "")
+}
+
+func (e *Epub) ncx() string {
+	var sb strings.Builder
+	order := 0
+	var write func(points []*navPoint, indent string)
+	write = func(points []*navPoint, indent string) {
+		for _, p := range points {
+			order++
+			sb.WriteString(fmt.Sprintf("%s<navPoint id=\"navPoint-%d\" playOrder=\"%d\">\n", indent, order, order))
+			sb.WriteString(fmt.Sprintf("%s  <navLabel><text>%s</text></navLabel>\n", indent, xmlEscape(p.title)))
+			sb.WriteString(fmt.Sprintf("%s  <content src=\"%s\"/>\n", indent, p.href))
+			write(p.children, indent+"  ")
+			sb.WriteString(indent + "</navPoint>\n")
+		}
+	}
+	write(e.navPoints(), "    ")
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<ncx xmlns="http://www.daisy.org/z3986/2005/ncx/" version="2005-1">
+  <head>
+    <meta name="dtb:uid" content="%s"/>
+  </head>
+  <docTitle><text>%s</text></docTitle>
+  <navMap>
+%s  </navMap>
+</ncx>
+`, xmlEscape(e.Identifier), xmlEscape(e.Title), sb.String())
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s)) // nolint
+	return buf.String()
+}
+
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, attr := range n.Attr {
+		if attr.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/yann0917/fs-gui/config"
 )
@@ -100,3 +102,19 @@ func MergeToMP4(paths []string, mergedFilePath string, filename string) error {
 	)
 	return runMergeCmd(cmd, paths, mergeFilePath)
 }
+
+// ExtractAudio 从视频中提取音轨，按目标文件扩展名选择编码：m4a 直接复制 AAC 音轨，其他转码为 mp3
+func ExtractAudio(videoPath, audioPath string) error {
+	ffmpegPath := getFfmpegPath()
+	cmds := []string{
+		"-y", "-i", videoPath, "-vn",
+	}
+	switch strings.ToLower(filepath.Ext(audioPath)) {
+	case ".m4a", ".aac":
+		cmds = append(cmds, "-c:a", "copy")
+	default:
+		cmds = append(cmds, "-c:a", "libmp3lame", "-q:a", "2")
+	}
+	cmds = append(cmds, audioPath)
+	return runMergeCmd(exec.Command(ffmpegPath, cmds...), nil, "")
+}
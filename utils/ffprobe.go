@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yann0917/fs-gui/config"
+)
+
+// MediaInfo ffprobe 探测到的媒体信息
+type MediaInfo struct {
+	Duration   float64 // 时长(秒)
+	Size       int64   // 文件大小(字节)
+	FormatName string  // 容器格式
+	Warning    string  // ffprobe 解析时输出的错误信息
+}
+
+// getFfprobePath 获取可用的ffprobe路径
+func getFfprobePath() string {
+	// 首先尝试使用配置文件中的路径
+	if configFfprobe := config.Conf.Ffprobe; configFfprobe != "" {
+		if _, err := os.Stat(configFfprobe); err == nil {
+			return configFfprobe
+		}
+	}
+
+	// 其次尝试与ffmpeg同目录的ffprobe
+	if ffmpegPath := getFfmpegPath(); filepath.IsAbs(ffmpegPath) {
+		name := strings.Replace(filepath.Base(ffmpegPath), "ffmpeg", "ffprobe", 1)
+		ffprobePath := filepath.Join(filepath.Dir(ffmpegPath), name)
+		if _, err := os.Stat(ffprobePath); err == nil {
+			return ffprobePath
+		}
+	}
+
+	// 最后尝试从PATH中查找
+	if path, err := exec.LookPath("ffprobe"); err == nil {
+		return path
+	}
+	return "ffprobe"
+}
+
+// CheckFfprobe 检查ffprobe是否可用
+func CheckFfprobe() error {
+	if _, err := exec.LookPath(getFfprobePath()); err != nil {
+		return fmt.Errorf("找不到ffprobe，请安装ffmpeg或在配置文件中设置ffprobe路径: %v", err)
+	}
+	return nil
+}
+
+// ProbeMedia 使用 ffprobe 获取音视频文件的时长和大小
+func ProbeMedia(fileName string) (info MediaInfo, err error) {
+	cmd := exec.Command(getFfprobePath(),
+		"-v", "error",
+		"-show_entries", "format=duration,size,format_name",
+		"-of", "json",
+		fileName,
+	)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return info, fmt.Errorf("ffprobe执行失败: %s\n错误输出: %s", err, stderr.String())
+	}
+
+	var result struct {
+		Format struct {
+			Duration   string `json:"duration"`
+			Size       string `json:"size"`
+			FormatName string `json:"format_name"`
+		} `json:"format"`
+	}
+	if err = UnmarshalJSON(stdout.Bytes(), &result); err != nil {
+		return info, fmt.Errorf("解析ffprobe输出失败: %v", err)
+	}
+	info.Duration, _ = strconv.ParseFloat(result.Format.Duration, 64)
+	info.Size, _ = strconv.ParseInt(result.Format.Size, 10, 64)
+	info.FormatName = result.Format.FormatName
+	info.Warning = strings.TrimSpace(stderr.String())
+	return
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
 
 	"github.com/SebastiaanKlippert/go-wkhtmltopdf"
 	"github.com/yann0917/fs-gui/config"
@@ -33,17 +34,93 @@ func getWkhtmltopdfPath() string {
 	return ""
 }
 
+// DefaultPdfProfile 未配置时使用的 PDF 版式
+const DefaultPdfProfile = "a4"
+
+// builtinPdfProfiles 内置 PDF 版式
+var builtinPdfProfiles = map[string]config.PdfProfile{
+	"a4": {
+		PageSize:    wkhtmltopdf.PageSizeA4,
+		FooterRight: "[page]",
+	},
+	"a5": {
+		PageSize:     wkhtmltopdf.PageSizeA5,
+		MarginTop:    12,
+		MarginBottom: 12,
+		MarginLeft:   12,
+		MarginRight:  12,
+		FontSize:     11,
+		HeaderCenter: "{title}",
+		FooterCenter: "[page]/[topage]",
+	},
+	// 6 英寸墨水屏
+	"eink6": {
+		PageWidth:    "90mm",
+		PageHeight:   "122mm",
+		MarginTop:    5,
+		MarginBottom: 6,
+		MarginLeft:   4,
+		MarginRight:  4,
+		FontSize:     14,
+		Toc:          true,
+		FooterCenter: "[page]/[topage]",
+	},
+}
+
+// PdfProfiles 所有可用的 PDF 版式，配置文件中的版式覆盖同名内置版式
+func PdfProfiles() map[string]config.PdfProfile {
+	profiles := make(map[string]config.PdfProfile, len(builtinPdfProfiles)+len(config.Conf.PdfProfiles))
+	for name, profile := range builtinPdfProfiles {
+		profiles[name] = profile
+	}
+	for name, profile := range config.Conf.PdfProfiles {
+		profiles[strings.ToLower(name)] = profile
+	}
+	return profiles
+}
+
+// GetPdfProfile 按名称获取 PDF 版式，名称为空时使用配置文件中的默认版式
+func GetPdfProfile(name string) (profile config.PdfProfile, err error) {
+	if name == "" {
+		name = config.Conf.PdfProfile
+	}
+	if name == "" {
+		name = DefaultPdfProfile
+	}
+	profile, ok := PdfProfiles()[strings.ToLower(name)]
+	if !ok {
+		return profile, fmt.Errorf("不存在的PDF版式: %s", name)
+	}
+	if o := profile.Orientation; o != "" && o != wkhtmltopdf.OrientationPortrait && o != wkhtmltopdf.OrientationLandscape {
+		return profile, fmt.Errorf("PDF版式 %s 的方向无效: %s", name, o)
+	}
+	return
+}
+
 type PdfOption struct {
 	FileName  string
 	CoverPath string
-	PageSize  string
-	Toc       bool
+	CoverHtml string // 封面页 HTML，版式开启封面时使用
+	Engine    string // PDF 引擎，为空时使用配置文件中的设置
+	Profile   config.PdfProfile
 	Title     string
-	Author    string
+	Author    string // 讲者，用于页眉页脚模板
 	Subject   string
 	Keywords  string
 }
 
+// template 替换页眉页脚模板中的书名和讲者
+func (p *PdfOption) template(s string) string {
+	return strings.NewReplacer("{title}", p.Title, "{speaker}", p.Author).Replace(s)
+}
+
+// setText 模板非空时设置页眉页脚
+func (p *PdfOption) setText(opt interface{ Set(string) }, tpl string) {
+	if tpl != "" {
+		opt.Set(p.template(tpl))
+	}
+}
+
 func (p *PdfOption) GenPdf(buf *bytes.Buffer) (err error) {
 	wkhtmltopdfPath := getWkhtmltopdfPath()
 	if wkhtmltopdfPath != "" {
@@ -55,10 +132,20 @@ func (p *PdfOption) GenPdf(buf *bytes.Buffer) (err error) {
 	if err != nil {
 		return fmt.Errorf("创建PDF生成器失败: %v", err)
 	}
+	profile := p.Profile
 
 	page := wkhtmltopdf.NewPageReader(buf)
+	page.HeaderFontSize.Set(10)
 	page.FooterFontSize.Set(10)
-	page.FooterRight.Set("[page]")
+	p.setText(&page.HeaderLeft, profile.HeaderLeft)
+	p.setText(&page.HeaderCenter, profile.HeaderCenter)
+	p.setText(&page.HeaderRight, profile.HeaderRight)
+	p.setText(&page.FooterLeft, profile.FooterLeft)
+	p.setText(&page.FooterCenter, profile.FooterCenter)
+	p.setText(&page.FooterRight, profile.FooterRight)
+	if profile.HeaderLeft != "" || profile.HeaderCenter != "" || profile.HeaderRight != "" {
+		page.HeaderSpacing.Set(3)
+	}
 	page.DisableSmartShrinking.Set(true)
 
 	page.EnableLocalFileAccess.Set(true)
@@ -78,7 +165,10 @@ func (p *PdfOption) GenPdf(buf *bytes.Buffer) (err error) {
 	}
 
 	pdfg.Dpi.Set(300)
-	if p.Toc {
+	if profile.Dpi > 0 {
+		pdfg.Dpi.Set(profile.Dpi)
+	}
+	if profile.Toc {
 		pdfg.TOC.Include = true
 		pdfg.TOC.TocHeaderText.Set("目 录")
 		pdfg.TOC.HeaderFontSize.Set(18)
@@ -89,12 +179,22 @@ func (p *PdfOption) GenPdf(buf *bytes.Buffer) (err error) {
 		pdfg.TOC.EnableTocBackLinks.Set(true)
 	}
 
-	pdfg.PageSize.Set(wkhtmltopdf.PageSizeA4)
+	if profile.PageWidth != "" && profile.PageHeight != "" {
+		pdfg.PageWidthUnit.Set(profile.PageWidth)
+		pdfg.PageHeightUnit.Set(profile.PageHeight)
+	} else if profile.PageSize != "" {
+		pdfg.PageSize.Set(profile.PageSize)
+	} else {
+		pdfg.PageSize.Set(wkhtmltopdf.PageSizeA4)
+	}
+	if profile.Orientation != "" {
+		pdfg.Orientation.Set(profile.Orientation)
+	}
 
-	pdfg.MarginTop.Set(15)
-	pdfg.MarginBottom.Set(15)
-	pdfg.MarginLeft.Set(15)
-	pdfg.MarginRight.Set(15)
+	pdfg.MarginTop.Set(marginOrDefault(profile.MarginTop))
+	pdfg.MarginBottom.Set(marginOrDefault(profile.MarginBottom))
+	pdfg.MarginLeft.Set(marginOrDefault(profile.MarginLeft))
+	pdfg.MarginRight.Set(marginOrDefault(profile.MarginRight))
 
 	// fmt.Printf("开始生成PDF...")
 	err = pdfg.Create()
@@ -114,3 +214,11 @@ func (p *PdfOption) GenPdf(buf *bytes.Buffer) (err error) {
 	}
 	return
 }
+
+// marginOrDefault 边距为 0 时使用默认的 15 毫米
+func marginOrDefault(margin uint) uint {
+	if margin == 0 {
+		return 15
+	}
+	return margin
+}
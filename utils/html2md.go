@@ -2,333 +2,624 @@ package utils
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
-	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
-var closeTag = map[string]string{
+// mdEmphasis 行内强调标签对应的 Markdown 标记
+var mdEmphasis = map[string]string{
 	"del":    "~~",
+	"s":      "~~",
+	"strike": "~~",
 	"b":      "**",
 	"strong": "**",
-	"i":      "_",
-	"em":     "_",
-	"dfn":    "_",
-	"var":    "_",
-	"cite":   "_",
-	"br":     "\n",
+	"i":      "*",
+	"em":     "*",
+	"dfn":    "*",
+	"var":    "*",
+	"cite":   "*",
 }
 
-var blockTag = []string{
-	"address", "div", "figure", "p", "figcaption",
-	"article", "aside", "nav", "footer", "fieldset", "menu",
-	"header", "section", "center", "frameset", "details", "summary",
+// mdBlockTag 块级容器，内容按段落输出
+var mdBlockTag = map[string]bool{
+	"address": true, "article": true, "aside": true, "body": true, "center": true,
+	"details": true, "dd": true, "div": true, "dl": true, "dt": true, "fieldset": true,
+	"figcaption": true, "figure": true, "footer": true, "form": true, "frameset": true,
+	"header": true, "html": true, "main": true, "menu": true, "nav": true, "p": true,
+	"section": true, "summary": true,
 }
 
-var nextLineTag = []string{
-	"pre", "blockquote", "table",
+var (
+	mdSpaceRegexp      = regexp.MustCompile(`[ \t\r\n\f]+`)
+	mdLineStartRegexp  = regexp.MustCompile(`^(#{1,6}(\s|$)|[-+*>=](\s|$)|\d+[.)](\s|$))`)
+	mdFootnoteIDRegexp = regexp.MustCompile(`^fn[-:_]?[\w-]+$`)
+)
+
+// mdConverter HTML 转 Markdown 的转换状态
+type mdConverter struct {
+	footnotes   map[string]int // 脚注元素 id -> 脚注序号
+	definitions []string       // 按序号排列的脚注内容
 }
 
 // Html2Md  html to markdown
-// 将html转成markdown
+// 将html转成markdown，支持 GFM 表格、嵌套列表、嵌套引用、脚注和代码
 func Html2Md(htmlStr string) (md string) {
-	fmt.Println(htmlStr)
-	var maps map[string]string
-	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(htmlStr))
-	doc = trimAttr(doc)
-	doc, maps = compress(doc)
-	fmt.Println(maps)
-	doc = handleNextLine(doc)  // <div>...
-	doc = handleBlockTag(doc)  // <div>...
-	doc = handleA(doc)         // <a>
-	doc = handleImg(doc)       // <img>
-	doc = handleHead(doc)      // h1~h6
-	doc = handleClosedTag(doc) // <strong>、<i>、eg..
-	doc = handleHr(doc)        // <hr>
-	doc = handleLi(doc)        // <li>
-	md, _ = doc.Find("body").Html()
-	md = depress(md, maps)
+	doc, err := html.Parse(strings.NewReader(htmlStr))
+	if err != nil {
+		return htmlStr
+	}
+	c := &mdConverter{footnotes: make(map[string]int)}
+	c.collectFootnotes(doc)
+
+	blocks := c.blocks(doc)
+	for i, def := range c.definitions {
+		blocks = append(blocks, fmt.Sprintf("[^%d]: %s", i+1, def))
+	}
+	md = strings.Join(blocks, "\n\n")
+	if md != "" {
+		md += "\n"
+	}
 	return
 }
 
-// 解压，释放code和pre
-func depress(md string, maps map[string]string) string {
-	// 先替换pre，再替换code，因为有的code在pre标签里面
-	for key, val := range maps {
-		if strings.HasPrefix(key, "{$blockquote") {
-			md = strings.Replace(md, key, "\n\r"+val+"\n\r", -1)
+// MdAssetsDir Markdown 文稿图片所在的目录，位于 .md 文件同级
+const MdAssetsDir = "assets"
+
+// LocalizeMdImages 将 HTML 中的远程图片下载到 mdFile 同级的 assets 目录并改写为相对地址
+// 图片以内容的 md5 命名，同目录下的多本书共用相同的图片文件
+// 返回改写后的 HTML 和下载失败的图片地址
+func LocalizeMdImages(content, mdFile string) (string, []string) {
+	dir := filepath.Join(filepath.Dir(mdFile), MdAssetsDir)
+	return rewriteImages(content, func(data []byte) (string, error) {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return "", err
+		}
+		_, ext := SniffImage(data)
+		name := Md5(BytesToString(data)) + "." + ext
+		fileName := filepath.Join(dir, name)
+		if !CheckFileExist(fileName) {
+			if err := os.WriteFile(fileName, data, 0644); err != nil {
+				return "", err
+			}
+		}
+		return MdAssetsDir + "/" + name, nil
+	})
+}
+
+// collectFootnotes 查找脚注定义：id 形如 fn1、fn:1 的元素，或 class 含 footnote 的列表中的列表项
+func (c *mdConverter) collectFootnotes(n *html.Node) {
+	if n.Type == html.ElementNode {
+		id := getAttr(n, "id")
+		isDef := mdFootnoteIDRegexp.MatchString(id) && !strings.HasPrefix(id, "fnref")
+		if !isDef && n.Data == "li" && id != "" && n.Parent != nil {
+			for p := n.Parent; p != nil; p = p.Parent {
+				if strings.Contains(getAttr(p, "class"), "footnote") {
+					isDef = true
+					break
+				}
+			}
+		}
+		if isDef {
+			c.footnotes[id] = len(c.footnotes) + 1
+			c.definitions = append(c.definitions, "")
+			return
 		}
 	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.collectFootnotes(child)
+	}
+}
+
+// isFootnoteDef 是否为脚注定义，脚注内容统一输出在文末
+func (c *mdConverter) isFootnoteDef(n *html.Node) bool {
+	num, ok := c.footnotes[getAttr(n, "id")]
+	if ok && c.definitions[num-1] == "" {
+		c.definitions[num-1] = strings.TrimSpace(mdSpaceRegexp.ReplaceAllString(c.inline(n, false), " "))
+	}
+	return ok
+}
+
+// isFootnoteSection 只包含脚注定义的容器，如 <section class="footnotes">
+func isFootnoteSection(n *html.Node) bool {
+	return n.Type == html.ElementNode && strings.Contains(getAttr(n, "class"), "footnote") && n.Data != "a" && n.Data != "sup"
+}
 
-	for key, val := range maps {
-		if strings.HasPrefix(key, "{$pre") {
-			md = strings.Replace(md, key, "\n\r"+val+"\n\r", -1)
+// blocks 将容器的子节点转换为 Markdown 块，连续的行内内容合并为一个段落
+func (c *mdConverter) blocks(n *html.Node) []string {
+	var blocks []string
+	var para strings.Builder
+	flush := func() {
+		if text := mdParagraph(para.String()); text != "" {
+			blocks = append(blocks, text)
 		}
+		para.Reset()
 	}
 
-	for key, val := range maps {
-		if strings.HasPrefix(key, "{$code") || strings.HasPrefix(key, "{$textarea") {
-			md = strings.Replace(md, key, val, -1)
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			if child.Type == html.TextNode {
+				para.WriteString(c.inline(child, false))
+			} else if child.Type == html.DocumentNode {
+				blocks = append(blocks, c.blocks(child)...)
+			}
+			continue
+		}
+		if c.isFootnoteDef(child) {
+			continue
+		}
+
+		switch tag := child.Data; {
+		case tag == "head":
+			flush()
+			if title := mdTitle(child); title != "" {
+				blocks = append(blocks, "# "+title)
+			}
+		case tag == "script" || tag == "style" || tag == "noscript" || tag == "template":
+		case len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6':
+			flush()
+			level, _ := strconv.Atoi(tag[1:])
+			if text := strings.TrimSpace(mdSpaceRegexp.ReplaceAllString(c.inline(child, false), " ")); text != "" {
+				blocks = append(blocks, strings.Repeat("#", level)+" "+text)
+			}
+		case tag == "ul" || tag == "ol":
+			flush()
+			if list := c.list(child); list != "" {
+				blocks = append(blocks, list)
+			}
+		case tag == "blockquote":
+			flush()
+			if quote := c.blockquote(child); quote != "" {
+				blocks = append(blocks, quote)
+			}
+		case tag == "pre":
+			flush()
+			blocks = append(blocks, mdCodeBlock(child))
+		case tag == "table":
+			flush()
+			if table := c.table(child); table != "" {
+				blocks = append(blocks, table)
+			}
+		case tag == "hr":
+			flush()
+			blocks = append(blocks, "---")
+		case tag == "li":
+			// 不在列表中的列表项按段落处理
+			flush()
+			blocks = append(blocks, c.blocks(child)...)
+		case mdBlockTag[tag]:
+			flush()
+			if isFootnoteSection(child) && len(c.footnotes) > 0 {
+				c.blocks(child) // 只提取脚注定义
+				continue
+			}
+			blocks = append(blocks, c.blocks(child)...)
+		default:
+			if containsBlock(child) {
+				flush()
+				blocks = append(blocks, c.blocks(child)...)
+			} else {
+				para.WriteString(c.inline(child, false))
+			}
 		}
 	}
+	flush()
+	return blocks
+}
 
-	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(md)); err == nil {
-		doc = trimAttr(doc)
-		md, _ = doc.Find("body").Html()
-		md = strings.Replace(md, "<span>", "", -1)
-		md = strings.Replace(md, "</span>", "", -1)
+// containsBlock 行内元素中是否嵌套了块级元素，如 <span><p>...</p></span>
+func containsBlock(n *html.Node) bool {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		switch child.Data {
+		case "ul", "ol", "blockquote", "pre", "table", "hr", "h1", "h2", "h3", "h4", "h5", "h6":
+			return true
+		}
+		if mdBlockTag[child.Data] || containsBlock(child) {
+			return true
+		}
 	}
-	return md
+	return false
 }
 
-// trip attr
-func trimAttr(doc *goquery.Document) *goquery.Document {
-	attrs := []string{
-		"border", "colspan", "rowspan", "style", "cellspacing",
-		"cellpadding", "bgcolor", "width", "align", "frame", "id", "class",
-	}
-	elements := []string{
-		"table", "thead", "tbody", "tr", "td", "th", "h1", "h2", "h3", "h4", "img",
-		"h5", "h6", "i", "em", "strong", "span", "br", "hr", "ul", "li", "ol",
-	}
-	elements = append(elements, blockTag...)
-	elements = append(elements, nextLineTag...)
-	for _, tag := range elements {
-		doc.Find(tag).Each(func(i int, selection *goquery.Selection) {
-			for _, attr := range attrs {
-				selection.RemoveAttr(attr)
-			}
-		})
+// mdParagraph 整理段落：合并空白、去除行首空格并转义行首的 Markdown 标记
+func mdParagraph(s string) string {
+	lines := strings.Split(s, "\n")
+	var out []string
+	for _, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		line = escapeLineStart(line)
+		if hardBreak {
+			line += "  "
+		}
+		out = append(out, line)
 	}
-	return doc
+	if len(out) == 0 {
+		return ""
+	}
+	out[len(out)-1] = strings.TrimRight(out[len(out)-1], " ")
+	return strings.Join(out, "\n")
 }
 
-// 压缩html
-func compress(doc *goquery.Document) (*goquery.Document, map[string]string) {
-	// blockquote、pre、code，并替换 span 为空
-	var maps = make(map[string]string)
-
-	if ele := doc.Find("textarea"); len(ele.Nodes) > 0 {
-		ele.Each(func(i int, selection *goquery.Selection) {
-			key := fmt.Sprintf("{$textarea%v}", i)
-			cont := "<textarea>" + getInnerHtml(selection) + "</textarea>"
-			selection.BeforeHtml(key)
-			selection.Remove()
-			maps[key] = cont
-		})
-	}
-
-	if ele := doc.Find("code"); len(ele.Nodes) > 0 {
-		ele.Each(func(i int, selection *goquery.Selection) {
-			key := fmt.Sprintf("{$code%v}", i)
-			cont := "<code>" + getInnerHtml(selection) + "</code>"
-			selection.BeforeHtml(key)
-			selection.Remove()
-			maps[key] = cont
-		})
-	}
-
-	if ele := doc.Find("pre"); len(ele.Nodes) > 0 {
-		ele.Each(func(i int, selection *goquery.Selection) {
-			key := fmt.Sprintf("{$pre%v}", i)
-			cont := "<pre>" + getInnerHtml(selection) + "</pre>"
-			selection.BeforeHtml(key)
-			selection.Remove()
-			maps[key] = cont
-		})
-	}
-
-	if ele := doc.Find("blockquote"); len(ele.Nodes) > 0 {
-		ele.Each(func(i int, selection *goquery.Selection) {
-			key := fmt.Sprintf("{$blockquote%v}", i)
-			cont := "<blockquote>" + getInnerHtml(selection) + "</blockquote>"
-			selection.BeforeHtml(key)
-			selection.Remove()
-			maps[key] = cont
-		})
-	}
-
-	htmlstr, _ := doc.Html()
-	htmlstr = strings.Replace(htmlstr, "\n", "", -1)
-	htmlstr = strings.Replace(htmlstr, "\r", "", -1)
-	htmlstr = strings.Replace(htmlstr, "\t", "", -1)
-	htmlstr = strings.Replace(htmlstr, "<dl", "<ul", -1)
-	htmlstr = strings.Replace(htmlstr, "</dl", "</ul", -1)
-	htmlstr = strings.Replace(htmlstr, "<dt", "<li", -1)
-	htmlstr = strings.Replace(htmlstr, "</dt", "</li", -1)
-	htmlstr = strings.Replace(htmlstr, "<dd", "<li", -1)
-	htmlstr = strings.Replace(htmlstr, "</dd", "</li", -1)
-	// 正则匹配，把“>”和“<”直接的空格全部去掉
-	// 去除标签之间的空格，如果是存在代码预览的页面，不要替换空格，否则预览的代码会错乱
-	r, _ := regexp.Compile(">\\s{1,}<")
-	htmlstr = r.ReplaceAllString(htmlstr, "> <")
-	// 多个空格替换成一个空格
-	r2, _ := regexp.Compile("\\s{1,}")
-	htmlstr = r2.ReplaceAllString(htmlstr, " ")
-	doc, _ = goquery.NewDocumentFromReader(strings.NewReader(htmlstr))
-	return doc, maps
+// escapeLineStart 行首的 #、-、>、1. 等会被解析为标题、列表或引用，需要转义
+func escapeLineStart(line string) string {
+	m := mdLineStartRegexp.FindString(line)
+	if m == "" {
+		return line
+	}
+	if i := strings.IndexAny(m, ".)"); i > 0 && unicode.IsDigit(rune(m[0])) {
+		return line[:i] + `\` + line[i:]
+	}
+	return `\` + line
 }
 
-func handleBlockTag(doc *goquery.Document) *goquery.Document {
-	for _, tag := range blockTag {
-		hasTag := true
-		for hasTag {
-			if tagEle := doc.Find(tag); len(tagEle.Nodes) > 0 {
-				tagEle.Each(func(i int, selection *goquery.Selection) {
-					selection.BeforeHtml("\n" + getInnerHtml(selection) + "\n")
-					selection.Remove()
-				})
-			} else {
-				hasTag = false
+// inline 转换行内内容，inTable 为 true 时换行输出为 <br>，竖线需要转义
+func (c *mdConverter) inline(n *html.Node, inTable bool) string {
+	switch n.Type {
+	case html.TextNode:
+		text := mdSpaceRegexp.ReplaceAllString(n.Data, " ")
+		text = escapeMd(text)
+		if inTable {
+			text = strings.ReplaceAll(text, "|", `\|`)
+		}
+		return text
+	case html.ElementNode:
+	default:
+		return c.children(n, inTable)
+	}
+
+	switch tag := n.Data; tag {
+	case "script", "style", "noscript", "template", "head":
+		return ""
+	case "br":
+		if inTable {
+			return "<br>"
+		}
+		return "  \n"
+	case "img":
+		src := getAttr(n, "data-src")
+		if src == "" {
+			src = getAttr(n, "src")
+		}
+		if src == "" {
+			return ""
+		}
+		return "![" + escapeMd(getAttr(n, "alt")) + "](" + mdUrl(src) + ")"
+	case "a":
+		href := getAttr(n, "href")
+		if strings.HasPrefix(href, "#") {
+			if num, ok := c.footnotes[href[1:]]; ok {
+				return fmt.Sprintf("[^%d]", num)
 			}
+			// 脚注中返回正文的链接
+			if strings.HasPrefix(href, "#fnref") || strings.Contains(getAttr(n, "class"), "backref") {
+				return ""
+			}
+		}
+		text := strings.TrimSpace(c.children(n, inTable))
+		if href == "" || strings.HasPrefix(href, "javascript:") {
+			return text
+		}
+		if text == "" {
+			return "<" + href + ">"
+		}
+		return "[" + text + "](" + mdUrl(href) + ")"
+	case "code", "kbd", "samp", "tt":
+		return mdCodeSpan(nodeText(n))
+	case "sup", "sub":
+		inner := c.children(n, inTable)
+		if strings.HasPrefix(strings.TrimSpace(inner), "[^") {
+			return strings.TrimSpace(inner)
 		}
+		if strings.TrimSpace(inner) == "" {
+			return ""
+		}
+		return "<" + tag + ">" + strings.TrimSpace(inner) + "</" + tag + ">"
+	case "ul", "ol", "table", "blockquote", "pre":
+		// 表格单元格、标题中的块级内容只保留文字
+		return " " + c.children(n, inTable) + " "
+	}
+
+	inner := c.children(n, inTable)
+	if mark, ok := mdEmphasis[n.Data]; ok {
+		return wrapEmphasis(inner, mark)
+	}
+	if mdBlockTag[n.Data] || n.Data == "li" {
+		if inTable {
+			return inner + "<br>"
+		}
+		return inner + "  \n"
 	}
-	return doc
+	return inner
 }
 
-// func handleBlockquote(doc *goquery.Document) *goquery.Document {
-//	if tagEle := doc.Find("blockquote"); len(tagEle.Nodes) > 0 {
-//		tagEle.Each(func(i int, selection *goquery.Selection) {
-//			cont := getInnerHtml(selection)
-//			cont = strings.Replace(cont, "\r", "", -1)
-//			cont = strings.Replace(cont, "\n", "", -1)
-//			selection.BeforeHtml("\r\n<blockquote>" + cont + "\n</blockquote>\n")
-//			selection.Remove()
-//		})
-//	}
-//
-//	doc.Find("code").Each(func(i int, selection *goquery.Selection) {
-//		fmt.Println(selection.Html())
-//	})
-//
-//	return doc
-// }
-
-// [ok]handle tag <a>
-func handleA(doc *goquery.Document) *goquery.Document {
-	doc.Find("a").Each(func(i int, selection *goquery.Selection) {
-		if href, ok := selection.Attr("href"); ok {
-			if cont, err := selection.Html(); err == nil {
-				md := fmt.Sprintf(`[%v](%v)`, cont, href)
-				selection.BeforeHtml(md)
-				selection.Remove()
-			}
+func (c *mdConverter) children(n *html.Node, inTable bool) string {
+	var sb strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && c.isFootnoteDef(child) {
+			continue
 		}
-	})
-	return doc
+		sb.WriteString(c.inline(child, inTable))
+	}
+	return sb.String()
 }
 
-// [ok]handle tag ul、ol、li
-// 处理步骤：
-// 1、先给每个li标签里面的内容加上"- "或者"\t- "
-// 2、提取li内容
-func handleLi(doc *goquery.Document) *goquery.Document {
-	var tags = []string{"ol", "ul", "li"}
-	doc.Find("li").Each(func(i int, selection *goquery.Selection) {
-		l := len(selection.ParentsFiltered("li").Nodes)
-		tab := strings.Join(make([]string, l+2), "{$@$space}")
-		selection.PrependHtml("\r$@$" + tab)
-	})
-	for _, tag := range tags {
-		doc.Find(tag).Each(func(i int, selection *goquery.Selection) {
-			if tag == "ul" {
-				selection.BeforeHtml("\n" + selection.Text() + "\n")
-			} else {
-				selection.BeforeHtml(selection.Text())
+// wrapEmphasis 添加强调标记，标记紧贴文字，首尾的空白移到标记外
+// 以标点结尾时在标记后补空格，否则中文等紧邻的文字会导致标记无法闭合
+func wrapEmphasis(inner, mark string) string {
+	text := strings.TrimSpace(inner)
+	if text == "" {
+		return inner
+	}
+	// 嵌套的同类标记合并，避免出现 ****
+	if strings.HasPrefix(text, mark) && strings.HasSuffix(text, mark) && len(text) > 2*len(mark) {
+		return inner
+	}
+	prefix, suffix := "", ""
+	if strings.TrimLeftFunc(inner, unicode.IsSpace) != inner {
+		prefix = " "
+	}
+	if strings.TrimRightFunc(inner, unicode.IsSpace) != inner {
+		suffix = " "
+	}
+	if first, _ := utf8.DecodeRuneInString(text); unicode.IsPunct(first) {
+		prefix = " "
+	}
+	if last, _ := utf8.DecodeLastRuneInString(text); unicode.IsPunct(last) {
+		suffix = " "
+	}
+	return prefix + mark + text + mark + suffix
+}
+
+// list 转换有序、无序列表，嵌套列表按列表标记的宽度缩进
+func (c *mdConverter) list(n *html.Node) string {
+	ordered := n.Data == "ol"
+	num := 1
+	if start, err := strconv.Atoi(getAttr(n, "start")); err == nil && ordered {
+		num = start
+	}
+
+	var items []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode {
+			continue
+		}
+		var blocks []string
+		if child.Data == "li" {
+			if c.isFootnoteDef(child) {
+				continue
+			}
+			blocks = c.blocks(child)
+		} else if child.Data == "ul" || child.Data == "ol" {
+			// 不规范的嵌套：<ul><li>a</li><ul>...</ul></ul>，归入上一项
+			if nested := c.list(child); nested != "" && len(items) > 0 {
+				items[len(items)-1] += "\n" + indentLines(nested, strings.Repeat(" ", markerWidth(ordered, num-1)))
+			}
+			continue
+		} else {
+			blocks = c.blocks(&html.Node{Type: html.ElementNode, Data: "li", FirstChild: child, LastChild: child})
+		}
+
+		marker := "-"
+		if ordered {
+			marker = strconv.Itoa(num) + "."
+			num++
+		}
+		// 列表项中的嵌套列表紧跟上一行，保持紧凑列表
+		var sb strings.Builder
+		for i, block := range blocks {
+			if i > 0 {
+				if isListBlock(block) {
+					sb.WriteString("\n")
+				} else {
+					sb.WriteString("\n\n")
+				}
 			}
-			selection.Remove()
-		})
+			sb.WriteString(block)
+		}
+		content := sb.String()
+		indent := strings.Repeat(" ", len(marker)+1)
+		items = append(items, marker+" "+strings.TrimPrefix(indentLines(content, indent), indent))
 	}
-	html, _ := doc.Find("body").Html()
-	for i := 10; i > 0; i-- {
-		oldTab := "$@$" + strings.Join(make([]string, i), "{$@$space}")
-		newTab := strings.Join(make([]string, i-1), "  ") + "- "
-		html = strings.Replace(html, oldTab, newTab, -1)
+	return strings.Join(items, "\n")
+}
+
+func markerWidth(ordered bool, num int) int {
+	if ordered {
+		return len(strconv.Itoa(num)) + 2
 	}
-	doc, _ = goquery.NewDocumentFromReader(strings.NewReader(html))
-	return doc
+	return 2
 }
 
-// [ok]handle tag <hr/>
-func handleHr(doc *goquery.Document) *goquery.Document {
-	doc.Find("hr").Each(func(i int, selection *goquery.Selection) {
-		selection.BeforeHtml("\n- - -\n")
-		selection.Remove()
-	})
-	return doc
+func isListBlock(block string) bool {
+	first := strings.SplitN(block, " ", 2)[0]
+	if first == "-" {
+		return true
+	}
+	_, err := strconv.Atoi(strings.TrimSuffix(first, "."))
+	return strings.HasSuffix(first, ".") && err == nil
 }
 
-// [ok]handle tag <img/>
-func handleImg(doc *goquery.Document) *goquery.Document {
-	doc.Find("img").Each(func(i int, selection *goquery.Selection) {
-		if src, ok := selection.Attr("src"); ok {
-			alt := ""
-			if val, ok := selection.Attr("alt"); ok {
-				alt = val
-			}
-			md := fmt.Sprintf(`![%v](%v)`, alt, src)
-			selection.BeforeHtml(md)
-			selection.Remove()
+// indentLines 每个非空行添加缩进
+func indentLines(s, indent string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
 		}
-	})
-	return doc
+	}
+	return strings.Join(lines, "\n")
 }
 
-// [ok]handle tag h1~h6
-func handleHead(doc *goquery.Document) *goquery.Document {
-	heads := map[string]string{
-		"title": "# ",
-		"h1":    "# ",
-		"h2":    "## ",
-		"h3":    "### ",
-		"h4":    "#### ",
-		"h5":    "##### ",
-		"h6":    "###### ",
-	}
-	for tag, replace := range heads {
-		doc.Find(tag).Each(func(i int, selection *goquery.Selection) {
-			text, _ := selection.Html()
-			selection.BeforeHtml("\n\r" + replace + text + "\n\r")
-			selection.Remove()
-		})
-	}
-	return doc
+// blockquote 转换引用，嵌套引用逐层添加 >
+func (c *mdConverter) blockquote(n *html.Node) string {
+	content := strings.Join(c.blocks(n), "\n\n")
+	if content == "" {
+		return ""
+	}
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
-func handleClosedTag(doc *goquery.Document) *goquery.Document {
-	for tag, sign := range closeTag {
-		doc.Find(tag).Each(func(i int, selection *goquery.Selection) {
-			if text, _ := selection.Html(); strings.TrimSpace(text) != "" {
-				t := []rune(strings.TrimSpace(text))
-				last := t[len(t)-1:]
-				if unicode.IsPunct(last[0]) {
-					selection.BeforeHtml(" " + sign + text + sign + " ")
-				} else {
-					selection.BeforeHtml(" " + sign + text + sign)
+// table 转换为 GFM 表格，第一行作为表头，合并的单元格补空单元格
+func (c *mdConverter) table(n *html.Node) string {
+	var rows [][]string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				var row []string
+				for cell := child.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+						continue
+					}
+					text := strings.TrimSpace(mdSpaceRegexp.ReplaceAllString(c.inline(cell, true), " "))
+					text = strings.TrimSuffix(strings.TrimSpace(strings.TrimSuffix(text, "<br>")), "<br>")
+					row = append(row, text)
+					if span, err := strconv.Atoi(getAttr(cell, "colspan")); err == nil {
+						for i := 1; i < span && i < 100; i++ {
+							row = append(row, "")
+						}
+					}
 				}
+				rows = append(rows, row)
+			case "table":
+				// 嵌套表格不单独输出
+			default:
+				walk(child)
+			}
+		}
+	}
+	walk(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := 0; i < cols; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = row[i]
 			}
-			selection.Remove()
-		})
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
 	}
-	return doc
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
 }
 
-func handleNextLine(doc *goquery.Document) *goquery.Document {
-	for _, tag := range nextLineTag {
-		doc.Find(tag).Each(func(i int, selection *goquery.Selection) {
-			selection.BeforeHtml("\n\n")
-			selection.AfterHtml("\n\n")
-		})
+// mdCodeBlock 转换代码块，围栏长度大于代码中最长的连续反引号
+func mdCodeBlock(n *html.Node) string {
+	lang := ""
+	for _, node := range []*html.Node{n, n.FirstChild} {
+		if node == nil || node.Type != html.ElementNode {
+			continue
+		}
+		for _, class := range strings.Fields(getAttr(node, "class")) {
+			if strings.HasPrefix(class, "language-") || strings.HasPrefix(class, "lang-") {
+				lang = class[strings.Index(class, "-")+1:]
+			}
+		}
 	}
-	return doc
+	code := strings.TrimRight(strings.TrimPrefix(nodeText(n), "\n"), "\n ")
+	fence := strings.Repeat("`", max(3, longestRun(code, '`')+1))
+	return fence + lang + "\n" + code + "\n" + fence
 }
 
-func getInnerHtml(selection *goquery.Selection) (html string) {
-	var err error
-	html, err = selection.Html()
-	if err != nil {
+// mdCodeSpan 转换行内代码
+func mdCodeSpan(code string) string {
+	code = mdSpaceRegexp.ReplaceAllString(code, " ")
+	if strings.TrimSpace(code) == "" {
+		return code
 	}
-	return
+	fence := strings.Repeat("`", longestRun(code, '`')+1)
+	if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+		code = " " + code + " "
+	}
+	return fence + code + fence
+}
+
+func longestRun(s string, r rune) int {
+	longest, cur := 0, 0
+	for _, c := range s {
+		if c == r {
+			cur++
+			longest = max(longest, cur)
+		} else {
+			cur = 0
+		}
+	}
+	return longest
+}
+
+// escapeMd 转义文本中的 Markdown 特殊字符
+func escapeMd(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		// 竖线只在表格中有特殊含义，由表格单元格转换时处理
+		case '\\', '`', '*', '_', '[', ']', '<', '>', '~':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// mdUrl 地址中有空格或括号时使用尖括号包裹
+func mdUrl(u string) string {
+	u = strings.TrimSpace(u)
+	if strings.ContainsAny(u, " ()<>") {
+		return "<" + strings.NewReplacer("<", "%3C", ">", "%3E").Replace(u) + ">"
+	}
+	return u
+}
+
+// mdTitle 页面标题
+func mdTitle(head *html.Node) string {
+	for child := head.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == "title" {
+			return strings.TrimSpace(mdSpaceRegexp.ReplaceAllString(escapeMd(nodeText(child)), " "))
+		}
+	}
+	return ""
 }
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的 golden 文件")
+
+// TestHtml2Md 对比 testdata/html2md 中每个 .html 的转换结果与同名 .md 文件
+// 修改转换规则后使用 go test ./utils -run TestHtml2Md -update 更新期望结果
+// api-*.html 为接口返回的文稿片段，使用 fs capture -book 书籍id 截取
+func TestHtml2Md(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "html2md", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("testdata/html2md 中没有测试文件")
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".html")
+		t.Run(name, func(t *testing.T) {
+			input, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Html2Md(string(input))
+
+			golden := strings.TrimSuffix(file, ".html") + ".md"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("Html2Md(%s) =\n%s\nwant:\n%s", file, got, want)
+			}
+		})
+	}
+}
@@ -3,29 +3,189 @@ package utils
 import (
 	"bytes"
 	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/yann0917/fs-gui/config"
 )
 
-func Html2Pdf(fileName, title, content string) (err error) {
+// Html2Pdf 按 opt.Profile 版式将 HTML 文稿生成 PDF，opt.Engine 指定渲染引擎
+func Html2Pdf(opt PdfOption, content string) (err error) {
+	engine, err := ResolvePdfEngine(opt.Engine)
+	if err != nil {
+		return err
+	}
+
+	// 远程图片下载到临时目录，避免网络较慢或离线时生成的 PDF 缺少图片
+	tmpDir, err := os.MkdirTemp("", "fs-pdf-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	content, failed := LocalizeImages(content, tmpDir)
+	var cover string
+	if opt.Profile.Cover && opt.CoverHtml != "" {
+		var coverFailed []string
+		cover, coverFailed = LocalizeImages(opt.CoverHtml, tmpDir)
+		failed = append(failed, coverFailed...)
+	}
+	for _, src := range failed {
+		fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败，PDF 中将缺少该图片\n", src)
+	}
+
+	if engine == PdfEngineBuiltin {
+		fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", opt.FileName)
+		return RenderPdf(opt, cover, content)
+	}
+
+	if cover != "" {
+		opt.CoverPath = filepath.Join(tmpDir, "cover.html")
+		if err = os.WriteFile(opt.CoverPath, []byte(genHeadHtml(opt.Profile)+cover+htmlFoot), 0644); err != nil {
+			return err
+		}
+	}
 
 	buf := new(bytes.Buffer)
 
-	article := genHeadHtml() + content + `
+	article := genHeadHtml(opt.Profile) + content + htmlFoot
+	buf.Write([]byte(article))
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", opt.FileName)
+	err = opt.GenPdf(buf)
+	return
+}
+
+const htmlFoot = `
 	</div>
 </body>
 </html>`
-	buf.Write([]byte(article))
-	pdf := PdfOption{
-		FileName: fileName,
-		Title:    title,
-		PageSize: "A4",
-		Toc:      false,
-	}
-	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
-	err = pdf.GenPdf(buf)
-	return
+
+// LocalizeImages 将 HTML 中的远程图片下载到 dir 并改写为本地地址，WebP 转为 PNG
+// 返回改写后的 HTML 和下载失败的图片地址
+func LocalizeImages(content, dir string) (string, []string) {
+	count := 0
+	return rewriteImages(content, func(data []byte) (string, error) {
+		count++
+		_, ext := SniffImage(data)
+		fileName := filepath.Join(dir, fmt.Sprintf("img_%03d.%s", count, ext))
+		if err := os.WriteFile(fileName, data, 0644); err != nil {
+			return "", err
+		}
+		return localFileUrl(fileName), nil
+	})
 }
 
-func genHeadHtml() (result string) {
+// rewriteImages 下载 HTML 中的远程图片，WebP 转为 PNG 后交给 save 保存，并将 src 改写为 save 返回的地址
+// 返回改写后的 HTML 和处理失败的图片地址
+func rewriteImages(content string, save func(data []byte) (string, error)) (string, []string) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return content, nil
+	}
+
+	var failed []string
+	rewritten := make(map[string]string)
+	doc.Find("img").Each(func(i int, s *goquery.Selection) {
+		src := s.AttrOr("data-src", "")
+		if src == "" {
+			src = s.AttrOr("src", "")
+		}
+		if strings.HasPrefix(src, "//") {
+			src = "https:" + src
+		}
+		if !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "https://") {
+			return
+		}
+
+		newSrc, ok := rewritten[src]
+		if !ok {
+			data, err := FetchBytes(src)
+			if err == nil {
+				data, err = ConvertWebp(data)
+			}
+			if err == nil {
+				newSrc, err = save(data)
+			}
+			if err != nil {
+				failed = append(failed, src)
+			}
+			rewritten[src] = newSrc
+		}
+		if newSrc == "" {
+			return
+		}
+		s.RemoveAttr("data-src")
+		s.SetAttr("src", newSrc)
+	})
+
+	res, err := doc.Find("body").Html()
+	if err != nil {
+		return content, failed
+	}
+	return res, failed
+}
+
+// localFileUrl 本地文件在 wkhtmltopdf 中的地址
+func localFileUrl(fileName string) string {
+	if runtime.GOOS == "windows" {
+		return fileName
+	}
+	return "file://" + fileName
+}
+
+// pdfFontFaces 生成 @font-face 规则，只使用本机安装或配置文件中指定的字体文件
+func pdfFontFaces() string {
+	families := []string{"FZFangSong-Z02", "FZKai-Z03", "PingFang SC"}
+	fonts := make(map[string]string, len(config.Conf.PdfFonts))
+	for family, file := range config.Conf.PdfFonts {
+		// 配置中的键会被转换为小写，CSS 中字体族名不区分大小写
+		fonts[strings.ToLower(family)] = file
+	}
+	var extra []string
+	for family := range fonts {
+		if !containsFold(families, family) {
+			extra = append(extra, family)
+		}
+	}
+	sort.Strings(extra)
+	families = append(families, extra...)
+
+	var sb strings.Builder
+	for _, family := range families {
+		src := fmt.Sprintf(`local("%s")`, family)
+		if file := fonts[strings.ToLower(family)]; file != "" {
+			if CheckFileExist(file) {
+				src += fmt.Sprintf(`, url("%s")`, localFileUrl(file))
+			} else {
+				fmt.Printf("【\033[31;1m%s\033[0m】字体文件不存在\n", file)
+			}
+		}
+		sb.WriteString(fmt.Sprintf("\t\t@font-face { font-family: \"%s\"; src:%s; }\n", family, src))
+	}
+	if file := config.Conf.PdfFont; file != "" {
+		if CheckFileExist(file) {
+			sb.WriteString(fmt.Sprintf("\t\t@font-face { font-family: \"PdfBody\"; src:url(\"%s\"); }\n", localFileUrl(file)))
+		} else {
+			fmt.Printf("【\033[31;1m%s\033[0m】字体文件不存在\n", file)
+		}
+	}
+	return sb.String()
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func genHeadHtml(profile config.PdfProfile) (result string) {
 	result = `<!DOCTYPE html>
 <html>
 <head>
@@ -33,15 +193,22 @@ func genHeadHtml() (result string) {
    <meta name="viewport" content="width=device-width, initial-scale=1.0">
 	<meta http-equiv="Content-Type" content="text/html; charset=utf-8" />
 	<style>
-		@font-face { font-family: "FZFangSong-Z02"; src:local("FZFangSong-Z02"), url("https://imgcdn.umiwi.com/ttf/fangzhengfangsong_gbk.ttf"); }
-		@font-face { font-family: "FZKai-Z03"; src:local("FZFangSong-Z02S"), url("https://imgcdn.umiwi.com/ttf/0315911813008928624065681028886857980055.ttf"); }
-		@font-face { font-family: "FZKai-Z03"; src:local("FZKai-Z03"), url("https://imgcdn.umiwi.com/ttf/fangzhengkaiti_gbk.ttf"); }
-		@font-face { font-family: "PingFang SC"; src:local("PingFang SC"); }
-		table, tr, td, th, tbody, thead, tfoot {page-break-inside: avoid !important;}
+` + pdfFontFaces() + articleCss(profile) + `	</style>
+</head>
+<body>
+<div class="course-content">
+`
+	return
+}
+
+// articleCss 文稿的公共样式，PDF 和离线 HTML 共用
+// 不包含 @font-face 规则，本地字体文件只在生成 PDF 时使用，离线 HTML 中的本地路径在其他设备上无效
+func articleCss(profile config.PdfProfile) string {
+	return `		table, tr, td, th, tbody, thead, tfoot {page-break-inside: avoid !important;}
 		img { page-break-inside: avoid; border-style: none;max-width: 100% !important;}
 		img.epub-footnote { padding-right:5px;}
 		img-info {page-break-inside: avoid; border-style: none;display: block;margin-top: -6px;margin-bottom: 18px;color: #A1A8AD;font-size: 12pt;text-align: center;line-height: 1.85;padding: 0 7px;}
-		body {font-family: "-apple-system-font", "BlinkMacSystemFont", "Microsoft YaHei UI", "Microsoft YaHei", "Source Han Serif SC", "Spectral", "Pingfang SC", "SFUI Text", "Source Han Serif SC", "Noto Sans CJK SC", "Roboto", "lucida grande", "lucida sans unicode", lucida, helvetica, "Hiragino Sans GB", "WenQuanYi Micro Hei";color:#4C4948;text-align:left;line-height:1.8;}
+		body {font-family: "PdfBody", "-apple-system-font", "BlinkMacSystemFont", "Microsoft YaHei UI", "Microsoft YaHei", "Source Han Serif SC", "Spectral", "Pingfang SC", "SFUI Text", "Source Han Serif SC", "Noto Sans CJK SC", "Roboto", "lucida grande", "lucida sans unicode", lucida, helvetica, "Hiragino Sans GB", "WenQuanYi Micro Hei";color:#4C4948;text-align:left;line-height:1.8;}
 		em {font-style: normal;}
 		h2>code { background-color: rgb(255, 96, 2);padding: 0.5%;border-radius: 10%;color: white;}
 		p>em {color: rgb(255, 96, 2);}
@@ -50,10 +217,18 @@ func genHeadHtml() (result string) {
            color: #2E4E6F;
            text-decoration: underline;
        }
-	</style>
-</head>
-<body>
-<div class="course-content">
-`
-	return
+		.book-cover {text-align:center;padding-top:15%;}
+		.book-cover img {max-width:60% !important;max-height:50%;}
+		.book-cover h1 {margin-top:1.5em;}
+		.book-cover .meta {color:#A1A8AD;}
+		.page-break {page-break-before: always;}
+` + pdfFontSize(profile)
+}
+
+// pdfFontSize 版式指定了正文字号时生成对应的样式
+func pdfFontSize(profile config.PdfProfile) string {
+	if profile.FontSize <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\t\tbody {font-size: %gpt;}\n", profile.FontSize)
 }
@@ -0,0 +1,169 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// imageExts 图片 MIME 类型对应的扩展名
+var imageExts = map[string]string{
+	"image/jpeg": "jpeg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+	"image/bmp":  "bmp",
+}
+
+// SniffImage 根据文件内容识别图片的 MIME 类型和扩展名，无法识别时按 JPEG 处理
+func SniffImage(data []byte) (mimeType, ext string) {
+	mimeType = http.DetectContentType(data)
+	if ext, ok := imageExts[mimeType]; ok {
+		return mimeType, ext
+	}
+	return "image/jpeg", "jpeg"
+}
+
+// ConvertWebp 将 WebP 图片转换为 PNG，其他格式原样返回
+func ConvertWebp(data []byte) ([]byte, error) {
+	if mimeType, _ := SniffImage(data); mimeType != "image/webp" {
+		return data, nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("解码WebP图片失败: %v", err)
+	}
+	return encodeImage(img, "image/png")
+}
+
+// PrepareCover 处理封面图：播放器普遍不支持的 WebP/BMP 转为 JPEG，
+// 最长边超过 maxSize 时等比缩小，maxSize 为 0 表示不缩放
+func PrepareCover(data []byte, maxSize int) (out []byte, mimeType string, err error) {
+	mimeType, _ = SniffImage(data)
+	convert := mimeType == "image/webp" || mimeType == "image/bmp"
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		// 无法解码的图片原样返回
+		return data, mimeType, nil
+	}
+	oversize := maxSize > 0 && (cfg.Width > maxSize || cfg.Height > maxSize)
+	if !convert && !oversize {
+		return data, mimeType, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("解码封面失败: %v", err)
+	}
+	if oversize {
+		img = resizeImage(img, maxSize)
+	}
+	if convert {
+		mimeType = "image/jpeg"
+	}
+	out, err = encodeImage(img, mimeType)
+	return
+}
+
+// DownloadImage 下载图片，按实际内容类型确定扩展名，WebP 转为 PNG
+// basePath 为不含扩展名的文件路径，返回实际保存的文件名
+func DownloadImage(basePath, fileUrl string) (fileName string, err error) {
+	data, err := FetchBytes(fileUrl)
+	if err != nil {
+		return
+	}
+	data, err = ConvertWebp(data)
+	if err != nil {
+		return
+	}
+	_, ext := SniffImage(data)
+	fileName = basePath + "." + ext
+
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err = os.WriteFile(fileName, data, 0644); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return
+}
+
+// FindImageFile 查找已下载的图片，basePath 为不含扩展名的文件路径
+func FindImageFile(basePath string) (string, bool) {
+	for _, ext := range []string{"jpeg", "jpg", "png", "gif", "webp", "bmp"} {
+		fileName := basePath + "." + ext
+		if CheckFileExist(fileName) {
+			return fileName, true
+		}
+	}
+	return "", false
+}
+
+// resizeImage 等比缩小到最长边不超过 maxSize
+func resizeImage(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w >= h {
+		h = h * maxSize / w
+		w = maxSize
+	} else {
+		w = w * maxSize / h
+		h = maxSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func encodeImage(img image.Image, mimeType string) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	var err error
+	switch mimeType {
+	case "image/png":
+		err = png.Encode(buf, img)
+	case "image/gif":
+		err = gif.Encode(buf, img, nil)
+	default:
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: 90})
+	}
+	return buf.Bytes(), err
+}
+
+// StitchImages 将多张图片纵向拼接为一张 PNG，宽度取最宽的图片，较窄的图片居中，背景为白色
+func StitchImages(images [][]byte) ([]byte, error) {
+	if len(images) == 0 {
+		return nil, fmt.Errorf("没有需要拼接的图片")
+	}
+	decoded := make([]image.Image, 0, len(images))
+	width, height := 0, 0
+	for i, data := range images {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("解码第 %d 张图片失败: %v", i+1, err)
+		}
+		decoded = append(decoded, img)
+		width = max(width, img.Bounds().Dx())
+		height += img.Bounds().Dy()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	y := 0
+	for _, img := range decoded {
+		bounds := img.Bounds()
+		x := (width - bounds.Dx()) / 2
+		draw.Draw(dst, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), img, bounds.Min, draw.Over)
+		y += bounds.Dy()
+	}
+	return encodeImage(dst, "image/png")
+}
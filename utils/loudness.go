@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+const (
+	// LoudnessModeGain 使用 ffmpeg 直接调整音量
+	LoudnessModeGain = "gain"
+	// LoudnessModeTag 仅写入 ReplayGain/iTunNORM 标签，由播放器调整音量
+	LoudnessModeTag = "tag"
+)
+
+// LoudnessOptions 响度标准化参数
+type LoudnessOptions struct {
+	Mode      string  // gain-使用ffmpeg调整音量, tag-写入ReplayGain/iTunNORM标签
+	Target    float64 // 目标响度(LUFS)，0 表示不处理
+	LufsValue float64 // 接口返回的原始响度(LUFS)
+	GainValue float64 // 接口返回的增益(dB)，对应接口的目标响度，仅供参考
+}
+
+// Gain 计算达到目标响度需要调整的增益(dB)，原始响度未知时 ok 为 false
+// 接口返回的增益对应接口自身的目标响度，与用户设置的目标无关，不能直接使用
+func (o LoudnessOptions) Gain() (gain float64, ok bool) {
+	if o.LufsValue == 0 {
+		return 0, false
+	}
+	return o.Target - o.LufsValue, true
+}
+
+// ebur128Integrated ebur128 滤镜汇总中的综合响度
+var ebur128Integrated = regexp.MustCompile(`I:\s+(-?[\d.]+) LUFS`)
+
+// MeasureLoudness 使用 ffmpeg 的 ebur128 滤镜测量文件的综合响度(LUFS)
+func MeasureLoudness(fileName string) (float64, error) {
+	cmd := exec.Command(getFfmpegPath(), "-hide_banner", "-nostats", "-i", fileName, "-map", "0:a:0", "-af", "ebur128", "-f", "null", "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("ffmpeg执行失败: %s\n错误输出: %s", err, stderr.String())
+	}
+	// 逐帧输出中也有 I: 字段，最后一个为汇总结果
+	matches := ebur128Integrated.FindAllStringSubmatch(stderr.String(), -1)
+	if len(matches) == 0 {
+		return 0, fmt.Errorf("无法测量响度")
+	}
+	lufs, err := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+	if err != nil || math.IsInf(lufs, 0) || lufs < -70 {
+		return 0, fmt.Errorf("无法测量响度: %s", matches[len(matches)-1][1])
+	}
+	return lufs, nil
+}
+
+// NormalizeLoudness 对音视频文件进行响度标准化，接口没有返回原始响度时使用 ffmpeg 测量
+func NormalizeLoudness(fileName string, opt LoudnessOptions) error {
+	if opt.Target == 0 {
+		return nil
+	}
+	// 只能为 mp3 写入 ReplayGain 标签，其他格式跳过，不改为调整音量重新编码
+	if opt.Mode == LoudnessModeTag && strings.ToLower(filepath.Ext(fileName)) != ".mp3" {
+		fmt.Printf("【\033[33;1m%s\033[0m】响度标签仅支持 mp3，跳过响度标准化\n", fileName)
+		return nil
+	}
+	gain, ok := opt.Gain()
+	if !ok {
+		lufs, err := MeasureLoudness(fileName)
+		if err != nil {
+			fmt.Printf("【\033[33;1m%s\033[0m】原始响度未知，跳过响度标准化: %v\n", fileName, err)
+			return nil
+		}
+		gain = opt.Target - lufs
+	}
+	if math.Abs(gain) < 0.1 {
+		return nil
+	}
+
+	fmt.Printf("响度标准化：【\033[37;1m%s\033[0m】 %+.2f dB ", fileName, gain)
+	var err error
+	if opt.Mode == LoudnessModeTag {
+		err = writeReplayGainTag(fileName, gain)
+	} else {
+		err = applyGain(fileName, gain)
+	}
+	if err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
+
+// applyGain 使用 ffmpeg 的 volume 滤镜调整音量，保留封面和元数据
+func applyGain(fileName string, gain float64) error {
+	ext := filepath.Ext(fileName)
+	tmpFile := strings.TrimSuffix(fileName, ext) + ".loudness" + ext
+
+	cmds := []string{
+		"-y", "-i", fileName,
+		"-map", "0:a", "-map", "0:v?", "-map_metadata", "0",
+		"-c:v", "copy",
+		"-af", fmt.Sprintf("volume=%.2fdB", gain),
+	}
+	if strings.ToLower(ext) == ".mp3" {
+		cmds = append(cmds, "-q:a", "2", "-id3v2_version", "3")
+	}
+	cmds = append(cmds, tmpFile)
+
+	if err := runMergeCmd(exec.Command(getFfmpegPath(), cmds...), nil, ""); err != nil {
+		os.Remove(tmpFile) // nolint
+		return err
+	}
+	return os.Rename(tmpFile, fileName)
+}
+
+// writeReplayGainTag 写入 ReplayGain 和 iTunNORM 标签
+func writeReplayGainTag(fileName string, gain float64) error {
+	tag, err := id3v2.Open(fileName, id3v2.Options{Parse: true})
+	if err != nil {
+		return err
+	}
+	defer tag.Close()
+
+	tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+		Encoding:    id3v2.EncodingUTF8,
+		Description: "REPLAYGAIN_TRACK_GAIN",
+		Value:       fmt.Sprintf("%+.2f dB", gain),
+	})
+	tag.AddCommentFrame(id3v2.CommentFrame{
+		Encoding:    id3v2.EncodingISO,
+		Language:    "eng",
+		Description: "iTunNORM",
+		Text:        iTunNorm(gain),
+	})
+	return tag.Save()
+}
+
+// iTunNORM 格式为10个8位十六进制数，前4个分别为左右声道基于 1/1000 W 和 1/2500 W 的音量调整值
+func iTunNorm(gain float64) string {
+	ratio := math.Pow(10, -gain/10)
+	values := []int{
+		clampNorm(1000 * ratio), clampNorm(1000 * ratio),
+		clampNorm(2500 * ratio), clampNorm(2500 * ratio),
+		0, 0, 0, 0, 0, 0,
+	}
+	var sb strings.Builder
+	for _, v := range values {
+		sb.WriteString(fmt.Sprintf(" %08X", v))
+	}
+	return sb.String()
+}
+
+func clampNorm(v float64) int {
+	if v > 65534 {
+		return 65534
+	}
+	return int(math.Round(v))
+}
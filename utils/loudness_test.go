@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoudnessOptions_Gain(t *testing.T) {
+	tests := []struct {
+		name   string
+		opt    LoudnessOptions
+		want   float64
+		wantOk bool
+	}{
+		{"调低", LoudnessOptions{Target: -16, LufsValue: -10}, -6, true},
+		{"调高", LoudnessOptions{Target: -16, LufsValue: -23.5}, 7.5, true},
+		{"已达目标", LoudnessOptions{Target: -16, LufsValue: -16}, 0, true},
+		{"忽略接口增益", LoudnessOptions{Target: -16, LufsValue: -20, GainValue: 2}, 4, true},
+		{"原始响度未知", LoudnessOptions{Target: -16, GainValue: 3}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.opt.Gain()
+			if ok != tt.wantOk || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Gain() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestITunNorm(t *testing.T) {
+	tests := []struct {
+		name string
+		gain float64
+		want string
+	}{
+		{"不调整", 0, " 000003E8 000003E8 000009C4 000009C4 00000000 00000000 00000000 00000000 00000000 00000000"},
+		{"调低 6dB", -6, " 00000F8D 00000F8D 000026E1 000026E1 00000000 00000000 00000000 00000000 00000000 00000000"},
+		{"调高 6dB", 6, " 000000FB 000000FB 00000274 00000274 00000000 00000000 00000000 00000000 00000000 00000000"},
+		{"超出上限", -30, " 0000FFFE 0000FFFE 0000FFFE 0000FFFE 00000000 00000000 00000000 00000000 00000000 00000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := iTunNorm(tt.gain); got != tt.want {
+				t.Errorf("iTunNorm(%v) = %q, want %q", tt.gain, got, tt.want)
+			}
+		})
+	}
+}
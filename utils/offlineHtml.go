@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"os"
+
+	"github.com/yann0917/fs-gui/config"
+)
+
+// offlineCss 离线 HTML 页面的布局样式，文稿样式与 PDF 共用
+const offlineCss = `		body {max-width: 800px;margin: 0 auto;padding: 24px 16px;}
+		.offline-header {display: flex;gap: 24px;align-items: flex-start;padding-bottom: 16px;margin-bottom: 24px;border-bottom: 1px solid #E5E5E5;}
+		.offline-header .cover {width: 160px;flex-shrink: 0;border-radius: 6px;}
+		.offline-header h1 {margin: 0 0 8px;font-size: 1.6em;}
+		.offline-header .meta {color: #A1A8AD;margin: 4px 0;}
+		.offline-header .summary {margin: 8px 0;}
+		.offline-header audio {width: 100%;margin-top: 8px;}
+		.mindmap img {display: block;margin: 12px auto;}
+		@media (max-width: 600px) {.offline-header {flex-direction: column;} .offline-header .cover {width: 120px;}}
+`
+
+// OfflineHtml 单文件离线 HTML 页面，图片以 base64 内嵌
+type OfflineHtml struct {
+	Title   string
+	Header  string // 页面顶部的书籍或节目信息
+	Content string // 文稿正文
+}
+
+// DataUri 将图片转换为 base64 data URI
+func DataUri(data []byte) string {
+	mimeType, _ := SniffImage(data)
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// InlineImages 将 HTML 中的远程图片以 base64 data URI 内嵌，WebP 转为 PNG
+// 返回改写后的 HTML 和下载失败的图片地址
+func InlineImages(content string) (string, []string) {
+	return rewriteImages(content, func(data []byte) (string, error) {
+		return DataUri(data), nil
+	})
+}
+
+// Write 内嵌图片后生成 HTML 文件
+func (h OfflineHtml) Write(fileName string) error {
+	header, failed := InlineImages(h.Header)
+	content, contentFailed := InlineImages(h.Content)
+	for _, src := range append(failed, contentFailed...) {
+		fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败，页面中将显示原地址\n", src)
+	}
+
+	page := `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>` + html.EscapeString(h.Title) + `</title>
+	<style>
+` + articleCss(config.PdfProfile{}) + offlineCss + `	</style>
+</head>
+<body>
+<header class="offline-header">
+` + header + `
+</header>
+<div class="course-content">
+` + content + `
+</div>
+</body>
+</html>
+`
+	fmt.Printf("正在生成文件：【\033[37;1m%s\033[0m】 ", fileName)
+	if err := os.WriteFile(fileName, []byte(page), 0644); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
@@ -0,0 +1,813 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/yann0917/fs-gui/config"
+	"golang.org/x/net/html"
+)
+
+const (
+	PdfEngineAuto        = "auto"        // 找到 wkhtmltopdf 时使用 wkhtmltopdf，否则使用内置引擎
+	PdfEngineWkhtmltopdf = "wkhtmltopdf" // 使用 wkhtmltopdf 渲染
+	PdfEngineBuiltin     = "builtin"     // 使用内置的纯 Go 引擎渲染
+)
+
+// cjkFontCandidates 未配置 pdfFont 时查找的系统 TrueType 中文字体
+var cjkFontCandidates = []string{
+	`C:\Windows\Fonts\simhei.ttf`,
+	`C:\Windows\Fonts\simkai.ttf`,
+	`C:\Windows\Fonts\simfang.ttf`,
+	"/Library/Fonts/Arial Unicode.ttf",
+	"/System/Library/Fonts/Supplemental/Arial Unicode.ttf",
+	"/usr/share/fonts/truetype/droid/DroidSansFallbackFull.ttf",
+	"/usr/share/fonts/google-droid-sans-fonts/DroidSansFallbackFull.ttf",
+	"/usr/share/fonts/truetype/noto/NotoSansSC-Regular.ttf",
+}
+
+// pageSizes 常用纸张大小(毫米)
+var pageSizes = map[string]fpdf.SizeType{
+	"a3":     {Wd: 297, Ht: 420},
+	"a4":     {Wd: 210, Ht: 297},
+	"a5":     {Wd: 148, Ht: 210},
+	"a6":     {Wd: 105, Ht: 148},
+	"b5":     {Wd: 176, Ht: 250},
+	"b6":     {Wd: 125, Ht: 176},
+	"letter": {Wd: 215.9, Ht: 279.4},
+	"legal":  {Wd: 215.9, Ht: 355.6},
+}
+
+// ResolvePdfEngine 确定实际使用的 PDF 引擎，名称为空时使用配置文件中的设置
+func ResolvePdfEngine(name string) (string, error) {
+	if name == "" {
+		name = config.Conf.PdfEngine
+	}
+	switch strings.ToLower(name) {
+	case "", PdfEngineAuto:
+		if wkhtmltopdfAvailable() {
+			return PdfEngineWkhtmltopdf, nil
+		}
+		fmt.Println("未找到wkhtmltopdf，使用内置PDF引擎")
+		return PdfEngineBuiltin, nil
+	case PdfEngineWkhtmltopdf:
+		return PdfEngineWkhtmltopdf, nil
+	case PdfEngineBuiltin:
+		return PdfEngineBuiltin, nil
+	}
+	return "", fmt.Errorf("不支持的PDF引擎: %s", name)
+}
+
+func wkhtmltopdfAvailable() bool {
+	if p := config.Conf.Wkhtmltopdf; p != "" && CheckFileExist(p) {
+		return true
+	}
+	if os.Getenv("WKHTMLTOPDF_PATH") != "" {
+		return true
+	}
+	_, err := exec.LookPath("wkhtmltopdf")
+	return err == nil
+}
+
+// findCjkFont 查找内置引擎使用的中文字体，只支持 TrueType 字体
+func findCjkFont() (string, error) {
+	if file := config.Conf.PdfFont; file != "" {
+		return file, checkTrueType(file)
+	}
+	for _, file := range cjkFontCandidates {
+		if checkTrueType(file) == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("找不到可用的中文字体，请在配置文件中将 pdfFont 设置为 TrueType(.ttf) 中文字体")
+}
+
+// checkTrueType 检查字体文件是否为内置引擎支持的 TrueType 字体
+func checkTrueType(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	head := make([]byte, 4)
+	if _, err = f.Read(head); err != nil {
+		return err
+	}
+	switch string(head) {
+	case "\x00\x01\x00\x00", "true":
+		return nil
+	case "ttcf":
+		return fmt.Errorf("内置PDF引擎不支持字体集合(.ttc): %s", file)
+	case "OTTO":
+		return fmt.Errorf("内置PDF引擎不支持 CFF 格式的 OpenType 字体: %s", file)
+	}
+	return fmt.Errorf("无法识别的字体文件: %s", file)
+}
+
+// parseLength 解析带单位的长度，返回毫米，支持 mm、cm、in、pt
+func parseLength(s string) (float64, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	units := []struct {
+		suffix string
+		mm     float64
+	}{{"mm", 1}, {"cm", 10}, {"in", 25.4}, {"pt", 25.4 / 72}}
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(s, u.suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("无效的长度: %s", s)
+			}
+			return v * u.mm, nil
+		}
+	}
+	return 0, fmt.Errorf("长度需要带单位(mm、cm、in、pt): %s", s)
+}
+
+// pdfPageSize 根据版式计算纸张大小
+func pdfPageSize(profile config.PdfProfile) (size fpdf.SizeType, err error) {
+	if profile.PageWidth != "" && profile.PageHeight != "" {
+		if size.Wd, err = parseLength(profile.PageWidth); err != nil {
+			return
+		}
+		size.Ht, err = parseLength(profile.PageHeight)
+		return
+	}
+	name := strings.ToLower(profile.PageSize)
+	if name == "" {
+		name = "a4"
+	}
+	size, ok := pageSizes[name]
+	if !ok {
+		return size, fmt.Errorf("内置PDF引擎不支持的纸张大小: %s", profile.PageSize)
+	}
+	return
+}
+
+const (
+	ptToMm     = 25.4 / 72
+	lineHeight = 1.8 // 与 HTML 样式中的行高一致
+	fontFamily = "cjk"
+)
+
+// pdfRun 一段样式相同的文本
+type pdfRun struct {
+	text   string
+	strong bool
+	em     bool
+	link   string
+}
+
+// pdfSegment 一行中样式相同的连续文本
+type pdfSegment struct {
+	run   *pdfRun
+	text  string
+	width float64
+}
+
+// pdfRenderer 内置 PDF 引擎，支持标题、段落、强调、列表、引用和图片
+type pdfRenderer struct {
+	pdf     *fpdf.Fpdf
+	opt     *PdfOption
+	hasBold bool
+
+	fontSize float64 // 正文字号(pt)
+	scale    float64 // 当前块的字号倍数
+	align    string
+	gray     bool // 引用等次要内容使用灰色
+
+	left, right, top, bottom float64
+	indent                   float64
+	y                        float64
+
+	runs      []*pdfRun
+	strong    int
+	em        int
+	link      string
+	lists     []int // 有序列表当前序号，无序列表为 -1
+	pre       int
+	cover     bool // 正在排版封面
+	coverPage int  // 封面所在页码，不显示页眉页脚
+	section   string
+	level     int // 上一个书签的层级
+	failed    []string
+	toc       []pdfTocEntry // 正文中的标题，版式开启目录时生成目录页
+	headings  int           // 已排版的标题数量，与 toc 的下标对应
+}
+
+// pdfTocEntry 目录项，页码在排版到对应标题后通过别名回填
+type pdfTocEntry struct {
+	title string
+	level int
+	link  int
+}
+
+// tocMaxLevel 目录页只列出三级及以上的标题，书签包含全部标题
+const tocMaxLevel = 3
+
+// tocAlias 目录项页码的占位符
+func tocAlias(i int) string {
+	return "{toc:" + strconv.Itoa(i) + "}"
+}
+
+// collectPdfHeadings 按排版顺序收集正文中的标题，跳过的节点与 walk 一致
+func collectPdfHeadings(content string) (toc []pdfTocEntry, err error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "script", "style", "head", "iframe", "noscript":
+				return
+			case "h1", "h2", "h3", "h4", "h5", "h6":
+				if title := strings.TrimSpace(nodeText(n)); title != "" {
+					toc = append(toc, pdfTocEntry{title: title, level: int(n.Data[1] - '0')})
+				}
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return
+}
+
+// RenderPdf 使用内置引擎将 HTML 文稿生成 PDF，图片需为本地文件
+func RenderPdf(opt PdfOption, cover, content string) (err error) {
+	profile := opt.Profile
+	size, err := pdfPageSize(profile)
+	if err != nil {
+		return
+	}
+	fontFile, err := findCjkFont()
+	if err != nil {
+		return
+	}
+
+	orientation := "P"
+	if strings.EqualFold(profile.Orientation, "Landscape") {
+		orientation = "L"
+	}
+	pdf := fpdf.NewCustom(&fpdf.InitType{OrientationStr: orientation, UnitStr: "mm", Size: size})
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetMargins(0, 0, 0)
+	pdf.AliasNbPages("{nb}")
+	pdf.SetTitle(opt.Title, true)
+	pdf.SetAuthor(opt.Author, true)
+	pdf.SetSubject(opt.Subject, true)
+	pdf.SetKeywords(opt.Keywords, true)
+	pdf.SetCreator("fs-gui", true)
+
+	// AddUTF8Font 会把路径拼接到字体目录下，直接读取字体文件以支持绝对路径
+	fontBytes, err := os.ReadFile(fontFile)
+	if err != nil {
+		return
+	}
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontBytes)
+	r := &pdfRenderer{
+		pdf:      pdf,
+		opt:      &opt,
+		fontSize: 12,
+		scale:    1,
+		align:    "L",
+		level:    -1,
+	}
+	if file := config.Conf.PdfFontBold; file != "" {
+		if err = checkTrueType(file); err != nil {
+			return
+		}
+		if fontBytes, err = os.ReadFile(file); err != nil {
+			return
+		}
+		pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBytes)
+		r.hasBold = true
+	}
+	if err = pdf.Error(); err != nil {
+		return fmt.Errorf("加载字体失败: %v", err)
+	}
+	if profile.FontSize > 0 {
+		r.fontSize = profile.FontSize
+	}
+	pageW, pageH := pdf.GetPageSize()
+	r.left = float64(marginOrDefault(profile.MarginLeft))
+	r.right = pageW - float64(marginOrDefault(profile.MarginRight))
+	r.top = float64(marginOrDefault(profile.MarginTop))
+	r.bottom = pageH - float64(marginOrDefault(profile.MarginBottom))
+	pdf.SetHeaderFunc(r.header)
+	pdf.SetFooterFunc(r.footer)
+
+	if profile.Cover && cover != "" {
+		r.cover, r.align = true, "C"
+		r.coverPage = pdf.PageNo() + 1
+		r.addPage()
+		r.y = r.top + (r.bottom-r.top)/6
+		if err = r.render(cover); err != nil {
+			return
+		}
+		r.cover, r.align = false, "L"
+	}
+	if profile.Toc {
+		if r.toc, err = collectPdfHeadings(content); err != nil {
+			return
+		}
+		if len(r.toc) > 0 {
+			r.addPage()
+			r.tocPage()
+		}
+	}
+	r.addPage()
+	if err = r.render(content); err != nil {
+		return
+	}
+	for _, src := range r.failed {
+		fmt.Printf("【\033[31;1m%s\033[0m】图片无法嵌入PDF\n", src)
+	}
+
+	if err = pdf.OutputFileAndClose(opt.FileName); err != nil {
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return fmt.Errorf("写入PDF文件失败: %v", err)
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return
+}
+
+// render 解析 HTML 片段并逐个节点排版
+func (r *pdfRenderer) render(content string) error {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return err
+	}
+	r.walk(doc)
+	r.flush()
+	return r.pdf.Error()
+}
+
+func (r *pdfRenderer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		r.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			r.walk(c)
+		}
+		return
+	}
+
+	switch n.Data {
+	case "script", "style", "head", "iframe", "noscript":
+		return
+	case "br":
+		r.runs = append(r.runs, &pdfRun{text: "\n"})
+		return
+	case "hr":
+		r.flush()
+		r.space(0.5)
+		r.ensure(2)
+		r.pdf.SetDrawColor(200, 200, 200)
+		r.pdf.Line(r.left+r.indent, r.y, r.right, r.y)
+		r.space(0.5)
+		return
+	case "img":
+		r.flush()
+		r.image(n)
+		return
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		r.heading(n)
+		return
+	}
+
+	block := isPdfBlock(n.Data)
+	if block {
+		r.flush()
+	}
+	if hasClass(n, "page-break") && r.y > r.top {
+		r.addPage()
+	}
+	restore := r.enter(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+	restore()
+	if block {
+		r.flush()
+		if n.Data == "p" {
+			r.space(0.4)
+		}
+	}
+}
+
+// enter 根据标签调整排版状态，返回恢复状态的函数
+func (r *pdfRenderer) enter(n *html.Node) func() {
+	if hasClass(n, "book-cover") {
+		align := r.align
+		r.align = "C"
+		return func() { r.align = align }
+	}
+	switch n.Data {
+	case "strong", "b":
+		r.strong++
+		return func() { r.strong-- }
+	case "em", "i":
+		r.em++
+		return func() { r.em-- }
+	case "a":
+		link := r.link
+		if href := getAttr(n, "href"); strings.HasPrefix(href, "http") {
+			r.link = href
+		}
+		return func() { r.link = link }
+	case "pre":
+		r.pre++
+		return func() { r.pre-- }
+	case "blockquote":
+		r.indent += 5
+		gray := r.gray
+		r.gray = true
+		return func() { r.indent -= 5; r.gray = gray }
+	case "ul", "ol":
+		start := -1
+		if n.Data == "ol" {
+			start = 1
+		}
+		r.lists = append(r.lists, start)
+		r.indent += 6
+		return func() { r.lists = r.lists[:len(r.lists)-1]; r.indent -= 6 }
+	case "li":
+		if len(r.lists) > 0 {
+			i := len(r.lists) - 1
+			marker := "• "
+			if r.lists[i] > 0 {
+				marker = strconv.Itoa(r.lists[i]) + ". "
+				r.lists[i]++
+			}
+			r.runs = append(r.runs, &pdfRun{text: marker})
+		}
+	case "td", "th":
+		r.runs = append(r.runs, &pdfRun{text: " "})
+	}
+	return func() {}
+}
+
+func hasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(getAttr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func isPdfBlock(tag string) bool {
+	switch tag {
+	case "p", "div", "section", "article", "blockquote", "ul", "ol", "li", "pre", "table", "tr", "figure", "figcaption":
+		return true
+	}
+	return false
+}
+
+// text 添加文本，pre 之外的连续空白合并为一个空格
+func (r *pdfRenderer) text(s string) {
+	if r.pre == 0 {
+		s = strings.Join(strings.Fields(s), " ")
+		if s == "" {
+			return
+		}
+	}
+	r.runs = append(r.runs, &pdfRun{
+		text:   s,
+		strong: r.strong > 0,
+		em:     r.em > 0,
+		link:   r.link,
+	})
+}
+
+// heading 标题：加大字号并生成书签
+func (r *pdfRenderer) heading(n *html.Node) {
+	r.flush()
+	level := int(n.Data[1] - '0')
+	scales := map[int]float64{1: 1.6, 2: 1.4, 3: 1.2}
+	scale, ok := scales[level]
+	if !ok {
+		scale = 1.1
+	}
+	r.space(0.6)
+	r.scale = scale
+	r.strong++
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		r.walk(c)
+	}
+	r.strong--
+
+	title := strings.TrimSpace(nodeText(n))
+	if title != "" && !r.cover {
+		r.ensure(r.lineHeight())
+		if level <= 2 {
+			r.section = title
+		}
+		// 书签层级每次最多加深一级
+		bm := level - 1
+		if bm > r.level+1 {
+			bm = r.level + 1
+		}
+		r.level = bm
+		r.pdf.Bookmark(title, bm, r.y)
+		if r.headings < len(r.toc) {
+			r.pdf.SetLink(r.toc[r.headings].link, r.y, r.pdf.PageNo())
+			r.pdf.RegisterAlias(tocAlias(r.headings), strconv.Itoa(r.pdf.PageNo()))
+		}
+		r.headings++
+	}
+	r.flush()
+	r.scale = 1
+	r.space(0.3)
+}
+
+// tocPage 目录页，目录项链接到正文中的标题，页码在排版正文时回填；占位符宽度与页码不同，页码左对齐
+func (r *pdfRenderer) tocPage() {
+	r.setStyle(&pdfRun{strong: true})
+	r.pdf.SetFontSize(r.fontSize * 1.4)
+	h := r.fontSize * 1.4 * ptToMm * lineHeight
+	r.pdf.SetXY(r.left, r.y)
+	r.pdf.CellFormat(r.right-r.left, h, "目 录", "", 0, "C", false, 0, "")
+	r.y += h * 1.5
+
+	size := r.fontSize * 0.9
+	h = size * ptToMm * lineHeight
+	numW := size * ptToMm * 4
+	for i := range r.toc {
+		entry := &r.toc[i]
+		entry.link = r.pdf.AddLink()
+		if entry.level > tocMaxLevel {
+			continue
+		}
+		r.ensure(h)
+		indent := float64(entry.level-1) * 5
+		titleW := r.right - r.left - indent - numW
+		style := ""
+		if entry.level == 1 && r.hasBold {
+			style = "B"
+		}
+		r.pdf.SetFont(fontFamily, style, size)
+		r.pdf.SetTextColor(0, 0, 0)
+		title := entry.title
+		// 过长的标题截断为一行，首行过短(如以空白开头)时不截断
+		if lines := r.pdf.SplitText(title, titleW); len(lines) > 1 {
+			if first := []rune(lines[0]); len(first) > 1 {
+				title = strings.TrimSpace(string(first[:len(first)-1])) + "…"
+			}
+		}
+		r.pdf.SetXY(r.left+indent, r.y)
+		r.pdf.CellFormat(titleW, h, title, "", 0, "L", false, entry.link, "")
+		r.pdf.SetFont(fontFamily, "", size)
+		r.pdf.SetTextColor(161, 168, 173)
+		r.pdf.CellFormat(numW, h, tocAlias(i), "", 0, "L", false, entry.link, "")
+		r.y += h
+	}
+}
+
+// image 嵌入本地图片，按内容宽度等比缩小
+func (r *pdfRenderer) image(n *html.Node) {
+	src := getAttr(n, "src")
+	file := strings.TrimPrefix(src, "file://")
+	imageType := strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+	if imageType == "jpeg" {
+		imageType = "jpg"
+	}
+	if !CheckFileExist(file) || (imageType != "jpg" && imageType != "png" && imageType != "gif") {
+		// 远程图片在本地化时已经报告过下载失败
+		if src != "" && !strings.HasPrefix(src, "http") {
+			r.failed = append(r.failed, src)
+		}
+		return
+	}
+
+	opt := fpdf.ImageOptions{ImageType: imageType}
+	info := r.pdf.RegisterImageOptions(file, opt)
+	if r.pdf.Err() || info == nil {
+		r.pdf.ClearError()
+		r.failed = append(r.failed, src)
+		return
+	}
+	maxW := r.right - r.left - r.indent
+	maxH := r.bottom - r.top
+	w, h := info.Width(), info.Height()
+	if w > maxW {
+		w, h = maxW, h*maxW/w
+	}
+	if h > maxH {
+		w, h = w*maxH/h, maxH
+	}
+	r.ensure(h)
+	x := r.left + r.indent
+	if r.align == "C" {
+		x += (maxW - w) / 2
+	}
+	r.pdf.ImageOptions(file, x, r.y, w, h, false, opt, 0, "")
+	r.y += h
+	r.space(0.4)
+}
+
+func (r *pdfRenderer) size() float64 {
+	return r.fontSize * r.scale
+}
+
+func (r *pdfRenderer) lineHeight() float64 {
+	return r.size() * ptToMm * lineHeight
+}
+
+// space 增加以行高为单位的垂直间距
+func (r *pdfRenderer) space(lines float64) {
+	r.y += r.lineHeight() * lines
+	if r.y > r.bottom {
+		r.addPage()
+	}
+}
+
+// ensure 剩余空间不足 h 时换页
+func (r *pdfRenderer) ensure(h float64) {
+	if r.y+h > r.bottom && r.y > r.top {
+		r.addPage()
+	}
+}
+
+func (r *pdfRenderer) addPage() {
+	r.pdf.AddPage()
+	r.y = r.top
+}
+
+// setStyle 设置文本的字体和颜色
+func (r *pdfRenderer) setStyle(run *pdfRun) {
+	style := ""
+	if run.strong && r.hasBold {
+		style = "B"
+	}
+	r.pdf.SetFont(fontFamily, style, r.size())
+	switch {
+	case run.link != "":
+		r.pdf.SetTextColor(46, 78, 111)
+	case run.em || (run.strong && !r.hasBold):
+		r.pdf.SetTextColor(255, 96, 2)
+	case r.gray:
+		r.pdf.SetTextColor(161, 168, 173)
+	default:
+		r.pdf.SetTextColor(76, 73, 72)
+	}
+}
+
+// flush 对当前段落的文本断行并输出
+func (r *pdfRenderer) flush() {
+	runs := r.runs
+	r.runs = nil
+	if len(runs) == 0 {
+		return
+	}
+	maxW := r.right - r.left - r.indent
+
+	var lines [][]pdfSegment
+	var line []pdfSegment
+	lineW := 0.0
+	newLine := func() {
+		lines = append(lines, line)
+		line, lineW = nil, 0
+	}
+	for _, run := range runs {
+		if run.text == "\n" {
+			newLine()
+			continue
+		}
+		r.setStyle(run)
+		for _, token := range splitPdfTokens(run.text) {
+			if token == "\n" {
+				newLine()
+				continue
+			}
+			if token == " " && lineW == 0 {
+				continue
+			}
+			w := r.pdf.GetStringWidth(token)
+			// 行首不放标点，溢出的标点悬挂在行尾
+			if lineW+w > maxW && lineW > 0 && !isClosingPunct(token) {
+				newLine()
+				if token == " " {
+					continue
+				}
+			}
+			if n := len(line); n > 0 && line[n-1].run == run {
+				line[n-1].text += token
+				line[n-1].width += w
+			} else {
+				line = append(line, pdfSegment{run: run, text: token, width: w})
+			}
+			lineW += w
+		}
+	}
+	if len(line) > 0 {
+		newLine()
+	}
+
+	h := r.lineHeight()
+	for _, segments := range lines {
+		r.ensure(h)
+		x := r.left + r.indent
+		if r.align == "C" {
+			width := 0.0
+			for _, seg := range segments {
+				width += seg.width
+			}
+			x += math.Max(0, (maxW-width)/2)
+		}
+		for _, seg := range segments {
+			r.setStyle(seg.run)
+			r.pdf.SetXY(x, r.y)
+			r.pdf.CellFormat(seg.width, h, seg.text, "", 0, "L", false, 0, seg.run.link)
+			x += seg.width
+		}
+		r.y += h
+	}
+}
+
+// splitPdfTokens 按可断行的位置切分文本：中日韩文字逐字切分，西文按单词切分
+func splitPdfTokens(s string) []string {
+	var tokens []string
+	var word strings.Builder
+	flushWord := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, c := range s {
+		switch {
+		case c == '\n':
+			flushWord()
+			tokens = append(tokens, "\n")
+		case unicode.IsSpace(c):
+			flushWord()
+			tokens = append(tokens, " ")
+		case isWideRune(c):
+			flushWord()
+			tokens = append(tokens, string(c))
+		default:
+			word.WriteRune(c)
+		}
+	}
+	flushWord()
+	return tokens
+}
+
+func isWideRune(c rune) bool {
+	return unicode.Is(unicode.Han, c) || unicode.Is(unicode.Hiragana, c) || unicode.Is(unicode.Katakana, c) ||
+		unicode.Is(unicode.Hangul, c) || (c >= 0x3000 && c <= 0x303F) || (c >= 0xFF00 && c <= 0xFFEF) ||
+		c == '“' || c == '”' || c == '‘' || c == '’' || c == '…' || c == '—'
+}
+
+func isClosingPunct(token string) bool {
+	return strings.Contains("，。、；：？！）》」』】”’…,.;:?!)", token) && len([]rune(token)) == 1
+}
+
+// header 页眉，封面页不显示
+func (r *pdfRenderer) header() {
+	p := r.opt.Profile
+	r.headerLine(p.HeaderLeft, p.HeaderCenter, p.HeaderRight, r.top/2-2.5)
+}
+
+// footer 页脚，封面页不显示
+func (r *pdfRenderer) footer() {
+	p := r.opt.Profile
+	_, pageH := r.pdf.GetPageSize()
+	r.headerLine(p.FooterLeft, p.FooterCenter, p.FooterRight, r.bottom+(pageH-r.bottom)/2-2.5)
+}
+
+func (r *pdfRenderer) headerLine(left, center, right string, y float64) {
+	if r.coverPage > 0 && r.pdf.PageNo() == r.coverPage {
+		return
+	}
+	r.pdf.SetFont(fontFamily, "", 9)
+	r.pdf.SetTextColor(161, 168, 173)
+	y = math.Max(y, 1)
+	for _, item := range []struct{ tpl, align string }{{left, "L"}, {center, "C"}, {right, "R"}} {
+		if item.tpl == "" {
+			continue
+		}
+		text := strings.NewReplacer(
+			"[page]", strconv.Itoa(r.pdf.PageNo()),
+			"[topage]", "{nb}",
+			"[section]", r.section,
+		).Replace(r.opt.template(item.tpl))
+		r.pdf.SetXY(r.left, y)
+		r.pdf.CellFormat(r.right-r.left, 5, text, "", 0, item.align, false, 0, "")
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+// TempoSuffix 倍速副本的文件名后缀，例如 _1.5x
+func TempoSuffix(tempo float64) string {
+	return "_" + strconv.FormatFloat(tempo, 'f', -1, 64) + "x"
+}
+
+// TempoFileName 倍速副本的文件名，例如 1.标题.mp3 -> 1.标题_1.5x.mp3
+func TempoFileName(fileName string, tempo float64) string {
+	ext := filepath.Ext(fileName)
+	return strings.TrimSuffix(fileName, ext) + TempoSuffix(tempo) + ext
+}
+
+// atempoFilter atempo 单个滤镜只支持 0.5~2.0 倍，超出范围时串联多个滤镜
+func atempoFilter(tempo float64) string {
+	var filters []string
+	for tempo > 2.0 {
+		filters = append(filters, "atempo=2.0")
+		tempo /= 2.0
+	}
+	for tempo < 0.5 {
+		filters = append(filters, "atempo=0.5")
+		tempo /= 0.5
+	}
+	filters = append(filters, "atempo="+strconv.FormatFloat(tempo, 'f', 4, 64))
+	return strings.Join(filters, ",")
+}
+
+// ChangeTempo 使用 ffmpeg 的 atempo 滤镜生成保持音调的倍速副本
+// duration 为原始时长(秒)，用于写入倍速后的时长标签
+func ChangeTempo(src, dst string, tempo float64, duration int) error {
+	fmt.Printf("正在生成倍速副本：【\033[37;1m%s\033[0m】 ", dst)
+
+	cmds := []string{
+		"-y", "-i", src,
+		"-map", "0:a", "-map", "0:v?", "-map_metadata", "0",
+		"-c:v", "copy",
+		"-filter:a", atempoFilter(tempo),
+	}
+	isMp3 := strings.ToLower(filepath.Ext(dst)) == ".mp3"
+	if isMp3 {
+		cmds = append(cmds, "-q:a", "2", "-id3v2_version", "3")
+	}
+	cmds = append(cmds, dst)
+
+	if err := runMergeCmd(exec.Command(getFfmpegPath(), cmds...), nil, ""); err != nil {
+		os.Remove(dst) // nolint
+		fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+		return err
+	}
+
+	if isMp3 {
+		if err := tagTempo(dst, tempo, duration); err != nil {
+			fmt.Printf("\033[31;1m%s\033[0m\n", "失败"+err.Error())
+			return err
+		}
+	}
+	fmt.Printf("\033[32;1m%s\033[0m\n", "完成")
+	return nil
+}
+
+// tagTempo 在标题中注明倍速，并写入倍速后的时长(TLEN，毫秒)
+func tagTempo(fileName string, tempo float64, duration int) error {
+	tag, err := id3v2.Open(fileName, id3v2.Options{Parse: true})
+	if err != nil {
+		return err
+	}
+	defer tag.Close()
+
+	if title := tag.Title(); title != "" {
+		tag.SetTitle(fmt.Sprintf("%s (%sx)", title, strconv.FormatFloat(tempo, 'f', -1, 64)))
+	}
+	if duration > 0 {
+		tag.DeleteFrames("TLEN")
+		length := int(float64(duration) * 1000 / tempo)
+		tag.AddTextFrame("TLEN", tag.DefaultEncoding(), strconv.Itoa(length))
+	}
+	return tag.Save()
+}
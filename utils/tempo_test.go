@@ -0,0 +1,42 @@
+package utils
+
+import "testing"
+
+func TestAtempoFilter(t *testing.T) {
+	tests := []struct {
+		tempo float64
+		want  string
+	}{
+		{1.5, "atempo=1.5000"},
+		{2, "atempo=2.0000"},
+		{0.5, "atempo=0.5000"},
+		{3, "atempo=2.0,atempo=1.5000"},
+		{4, "atempo=2.0,atempo=2.0000"},
+		{5, "atempo=2.0,atempo=2.0,atempo=1.2500"},
+		{0.3, "atempo=0.5,atempo=0.6000"},
+		{0.25, "atempo=0.5,atempo=0.5000"},
+	}
+	for _, tt := range tests {
+		if got := atempoFilter(tt.tempo); got != tt.want {
+			t.Errorf("atempoFilter(%v) = %q, want %q", tt.tempo, got, tt.want)
+		}
+	}
+}
+
+func TestTempoFileName(t *testing.T) {
+	tests := []struct {
+		fileName string
+		tempo    float64
+		want     string
+	}{
+		{"1.标题.mp3", 1.5, "1.标题_1.5x.mp3"},
+		{"1.标题.mp3", 2, "1.标题_2x.mp3"},
+		{"out/书名.m4a", 0.75, "out/书名_0.75x.m4a"},
+		{"无后缀", 1.25, "无后缀_1.25x"},
+	}
+	for _, tt := range tests {
+		if got := TempoFileName(tt.fileName, tt.tempo); got != tt.want {
+			t.Errorf("TempoFileName(%q, %v) = %q, want %q", tt.fileName, tt.tempo, got, tt.want)
+		}
+	}
+}
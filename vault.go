@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// 笔记库目录结构：笔记库/书籍 存放每本书的笔记，讲者、分类 目录存放索引笔记
+const (
+	vaultSubDir      = "笔记库"
+	vaultBookDir     = "书籍"
+	vaultSpeakerDir  = "讲者"
+	vaultCategoryDir = "分类"
+)
+
+// vaultFrontMatter 书籍笔记的 YAML 头信息
+type vaultFrontMatter struct {
+	BookId       int      `yaml:"bookId"`
+	Title        string   `yaml:"title"`
+	Authors      []string `yaml:"authors,omitempty"`
+	Speakers     []string `yaml:"speakers,omitempty"`
+	Score        string   `yaml:"score,omitempty"`
+	PublishTime  string   `yaml:"publishTime,omitempty"`
+	BusinessType int      `yaml:"businessType"`
+	Category     string   `yaml:"category,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+}
+
+// vaultNoteName 笔记名称，去掉 wikilink 中有特殊含义的字符
+func vaultNoteName(title string) string {
+	title = strings.NewReplacer("#", " ", "^", " ", "[", "(", "]", ")").Replace(title)
+	return utils.FileName(strings.Join(strings.Fields(title), " "), "")
+}
+
+// vaultLink 指向笔记的 wikilink
+func vaultLink(title string) string {
+	return "[[" + vaultNoteName(title) + "]]"
+}
+
+// vaultBookNoteName 书籍笔记名称，与其他导出一致以 bookId 开头，同名的书不会互相覆盖
+func vaultBookNoteName(bookID int, title string) string {
+	return vaultNoteName(utils.Int2String(bookID) + "." + title)
+}
+
+// vaultBookLink 指向书籍笔记的 wikilink，显示为书名
+func vaultBookLink(bookID int, title string) string {
+	return "[[" + vaultBookNoteName(bookID, title) + "|" + vaultNoteName(title) + "]]"
+}
+
+// vaultTag 标签中不能有空格
+func vaultTag(tag string) string {
+	return strings.Join(strings.Fields(strings.TrimPrefix(tag, "#")), "_")
+}
+
+// vaultNotePath 书籍笔记的保存路径
+func vaultNotePath(bookID int, title string) (string, error) {
+	dir, err := utils.Mkdir(OutputDir, vaultSubDir, vaultBookDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, vaultBookNoteName(bookID, title)+".md"), nil
+}
+
+// bookFrontMatter 根据书籍详情生成头信息，分类为书籍所属的栏目
+func bookFrontMatter(detail services.BookContent, businessType int) (fm vaultFrontMatter) {
+	fm.BookId = detail.BookInfo.BookId
+	fm.Title = strings.TrimSpace(detail.BookInfo.Title)
+	fm.Score = detail.BookInfo.Score
+	if detail.BookInfo.PublishTime > 0 {
+		fm.PublishTime = utils.UnixMilli2DateString(detail.BookInfo.PublishTime)
+	}
+	fm.BusinessType = businessType
+	fm.Category = getSubDir(businessType)
+
+	tags := map[string]bool{}
+	addTag := func(tag string) {
+		if tag = vaultTag(tag); tag != "" && !tags[tag] {
+			tags[tag] = true
+			fm.Tags = append(fm.Tags, tag)
+		}
+	}
+	addTag(fm.Category)
+	for _, author := range detail.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			fm.Authors = append(fm.Authors, name)
+		}
+		for _, tag := range author.Tags {
+			addTag(tag)
+		}
+	}
+	for _, speaker := range detail.Speakers {
+		if name := strings.TrimSpace(speaker.Name); name != "" {
+			fm.Speakers = append(fm.Speakers, name)
+		}
+	}
+	if len(fm.Speakers) == 0 && detail.BookInfo.SpeakerName != "" {
+		fm.Speakers = append(fm.Speakers, strings.TrimSpace(detail.BookInfo.SpeakerName))
+	}
+	return
+}
+
+// genVaultNote 生成 Obsidian/Logseq 笔记：YAML 头信息、讲者和分类链接、解读文稿及相关推荐
+func genVaultNote(fileName string, detail services.BookContent, businessType int, article string, opt DownloadOptions) error {
+	fm := bookFrontMatter(detail, businessType)
+	var front bytes.Buffer
+	enc := yaml.NewEncoder(&front)
+	enc.SetIndent(2)
+	if err := enc.Encode(fm); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	sb.Write(front.Bytes())
+	sb.WriteString("---\n\n")
+	sb.WriteString("# " + fm.Title + "\n\n")
+
+	var links []string
+	for _, speaker := range fm.Speakers {
+		links = append(links, vaultLink(speaker))
+	}
+	if len(links) > 0 {
+		sb.WriteString("讲者：" + strings.Join(links, "、") + "\n")
+	}
+	sb.WriteString("分类：" + vaultLink(fm.Category) + "\n\n")
+
+	if opt.LocalImages {
+		var failed []string
+		article, failed = utils.LocalizeMdImages(article, fileName)
+		for _, src := range failed {
+			fmt.Printf("【\033[31;1m%s\033[0m】图片下载失败，文稿中将保留原地址\n", src)
+		}
+	}
+	if md := strings.TrimSpace(utils.Html2Md(article)); md != "" {
+		sb.WriteString(md + "\n\n")
+	}
+
+	if len(detail.RecommendBook) > 0 {
+		sb.WriteString("## 相关推荐\n\n")
+		for _, book := range detail.RecommendBook {
+			if title := strings.TrimSpace(book.Title); title != "" {
+				sb.WriteString("- " + vaultBookLink(book.BookId, title) + "\n")
+			}
+		}
+	}
+
+	if err := utils.SaveFile(fileName, strings.TrimRight(sb.String(), "\n")+"\n"); err != nil {
+		return err
+	}
+	return updateVaultIndex(filepath.Dir(filepath.Dir(fileName)))
+}
+
+// readVaultFrontMatter 读取笔记的 YAML 头信息
+func readVaultFrontMatter(fileName string) (fm vaultFrontMatter, err error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	if !bytes.HasPrefix(data, []byte("---\n")) {
+		return fm, fmt.Errorf("缺少头信息: %s", fileName)
+	}
+	end := bytes.Index(data[4:], []byte("\n---"))
+	if end < 0 {
+		return fm, fmt.Errorf("头信息不完整: %s", fileName)
+	}
+	err = yaml.Unmarshal(data[4:4+end+1], &fm)
+	return
+}
+
+// updateVaultIndex 根据笔记库中所有书籍笔记的头信息重新生成讲者和分类索引
+func updateVaultIndex(vaultDir string) error {
+	notes, err := filepath.Glob(filepath.Join(vaultDir, vaultBookDir, "*.md"))
+	if err != nil {
+		return err
+	}
+
+	speakers := make(map[string][]vaultFrontMatter)
+	categories := make(map[string][]vaultFrontMatter)
+	for _, note := range notes {
+		fm, err := readVaultFrontMatter(note)
+		if err != nil {
+			fmt.Printf("【\033[31;1m%s\033[0m】%v\n", filepath.Base(note), err)
+			continue
+		}
+		for _, speaker := range fm.Speakers {
+			speakers[speaker] = append(speakers[speaker], fm)
+		}
+		if fm.Category != "" {
+			categories[fm.Category] = append(categories[fm.Category], fm)
+		}
+	}
+
+	if err = writeVaultIndex(filepath.Join(vaultDir, vaultSpeakerDir), "speaker", speakers); err != nil {
+		return err
+	}
+	return writeVaultIndex(filepath.Join(vaultDir, vaultCategoryDir), "category", categories)
+}
+
+// writeVaultIndex 每个讲者或分类生成一篇索引笔记，书籍按发布时间倒序排列
+func writeVaultIndex(dir, kind string, groups map[string][]vaultFrontMatter) error {
+	if len(groups) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+	for name, books := range groups {
+		sort.SliceStable(books, func(i, j int) bool {
+			if books[i].PublishTime != books[j].PublishTime {
+				return books[i].PublishTime > books[j].PublishTime
+			}
+			return books[i].BookId < books[j].BookId
+		})
+
+		var sb strings.Builder
+		sb.WriteString("---\ntype: " + kind + "\ntags:\n  - " + kind + "\n---\n\n")
+		sb.WriteString("# " + name + "\n\n")
+		for _, book := range books {
+			sb.WriteString("- " + vaultBookLink(book.BookId, book.Title))
+			var meta []string
+			if book.PublishTime != "" {
+				meta = append(meta, book.PublishTime)
+			}
+			if book.Score != "" {
+				meta = append(meta, book.Score+"分")
+			}
+			if len(meta) > 0 {
+				sb.WriteString(" · " + strings.Join(meta, " · "))
+			}
+			sb.WriteString("\n")
+		}
+		if err := utils.WriteFileWithTrunc(filepath.Join(dir, vaultNoteName(name)+".md"), sb.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yann0917/fs-gui/services"
+	"github.com/yann0917/fs-gui/utils"
+)
+
+// courseManifestName 课程目录下记录课程信息的文件名
+const courseManifestName = ".course.json"
+
+// CourseManifest 课程目录的课程信息，供校验等离线操作识别课程
+type CourseManifest struct {
+	CourseId int    `json:"courseId"`
+	Title    string `json:"title"`
+}
+
+func writeCourseManifest(dir string, courseID int, title string) error {
+	data, err := utils.MarshalJSON(CourseManifest{CourseId: courseID, Title: title})
+	if err != nil {
+		return err
+	}
+	return utils.WriteFileWithTrunc(filepath.Join(dir, courseManifestName), string(data))
+}
+
+func readCourseManifest(dir string) (manifest CourseManifest, err error) {
+	data, err := os.ReadFile(filepath.Join(dir, courseManifestName))
+	if err != nil {
+		return
+	}
+	err = utils.UnmarshalJSON(data, &manifest)
+	return
+}
+
+const (
+	VerifyStatusOk        = "ok"
+	VerifyStatusTruncated = "truncated" // 时长或大小明显不足
+	VerifyStatusCorrupt   = "corrupt"   // ffprobe 无法解析
+	VerifyStatusUnknown   = "unknown"   // 文件可读，但无法匹配到接口数据
+)
+
+// VerifyResult 单个文件的校验结果
+type VerifyResult struct {
+	File             string  `json:"file"`
+	Kind             string  `json:"kind"` // book | course
+	ID               int     `json:"id"`   // bookId 或 courseId
+	BusinessType     int     `json:"-"`
+	SubDir           string  `json:"-"` // 不在栏目目录下的书籍文件(如按讲者下载)相对于输出目录的目录，重新下载时保存到原目录
+	DownloadType     int     `json:"downloadType"`
+	Tempo            float64 `json:"tempo,omitempty"` // 倍速副本的倍速
+	Status           string  `json:"status"`
+	Duration         float64 `json:"duration"`         // 实测时长(秒)
+	ExpectedDuration int     `json:"expectedDuration"` // 接口返回的时长(秒)
+	Size             int64   `json:"size"`
+	ExpectedSize     int     `json:"expectedSize"`
+	Message          string  `json:"message,omitempty"`
+}
+
+// VerifyReport 校验报告，Results 只包含非 ok 的文件
+type VerifyReport struct {
+	Total     int            `json:"total"`
+	Ok        int            `json:"ok"`
+	Truncated int            `json:"truncated"`
+	Corrupt   int            `json:"corrupt"`
+	Unknown   int            `json:"unknown"`
+	Results   []VerifyResult `json:"results"`
+}
+
+var (
+	verifyMediaExts = map[string]int{".mp3": 1, ".m4a": 1, ".mp4": 2}
+	tempoNameRegexp = regexp.MustCompile(`^(.*)_(\d+(?:\.\d+)?)x$`)
+	bookIDRegexp    = regexp.MustCompile(`^(\d+)\.`)
+)
+
+// verifier 校验过程中缓存接口数据，避免同一本书、同一门课程重复请求
+type verifier struct {
+	root     string
+	books    map[int]*services.BookContent
+	programs map[string]map[string]services.Program // 课程目录 -> 文件名 -> 节目
+	manifest map[string]CourseManifest
+}
+
+// Verify 使用 ffprobe 校验目录下的所有音视频文件，progress 不为空时每校验一个文件回调一次
+func Verify(root string, progress func(current, total int)) (report VerifyReport, err error) {
+	if err = utils.CheckFfprobe(); err != nil {
+		return
+	}
+	var files []string
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		if _, ok := verifyMediaExts[ext]; !ok || strings.Contains(d.Name(), ".loudness.") {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	v := &verifier{
+		root:     root,
+		books:    make(map[int]*services.BookContent),
+		programs: make(map[string]map[string]services.Program),
+		manifest: make(map[string]CourseManifest),
+	}
+	for i, path := range files {
+		res := v.verifyFile(path)
+		if progress != nil {
+			progress(i+1, len(files))
+		}
+		report.Total++
+		switch res.Status {
+		case VerifyStatusOk:
+			report.Ok++
+			continue
+		case VerifyStatusTruncated:
+			report.Truncated++
+		case VerifyStatusCorrupt:
+			report.Corrupt++
+		default:
+			report.Unknown++
+		}
+		report.Results = append(report.Results, res)
+	}
+	return
+}
+
+// splitTempoName 拆分倍速副本的文件名，如 1.标题_1.5x -> 1.标题, 1.5
+// 倍速不在 0.5~4 之间时不是倍速副本，ok 为 false
+func splitTempoName(base string) (name string, tempo float64, ok bool) {
+	m := tempoNameRegexp.FindStringSubmatch(base)
+	if m == nil {
+		return base, 0, false
+	}
+	tempo, err := strconv.ParseFloat(m[2], 64)
+	if err != nil || tempo == 1 || tempo < minTempo || tempo > maxTempo {
+		return base, 0, false
+	}
+	return m[1], tempo, true
+}
+
+func (v *verifier) verifyFile(path string) (res VerifyResult) {
+	ext := filepath.Ext(path)
+	res.File = path
+	res.DownloadType = verifyMediaExts[strings.ToLower(ext)]
+	v.fillExpected(&res, path, strings.TrimSuffix(filepath.Base(path), ext))
+
+	info, err := utils.ProbeMedia(path)
+	if err != nil {
+		res.Status = VerifyStatusCorrupt
+		res.Message = err.Error()
+		return
+	}
+	checkMedia(&res, info)
+	return
+}
+
+// checkMedia 对比实测的时长、大小与接口数据，未匹配到接口数据的文件只检查能否读取时长
+func checkMedia(res *VerifyResult, info utils.MediaInfo) {
+	res.Duration = info.Duration
+	res.Size = info.Size
+	if info.Duration <= 0 {
+		res.Status = VerifyStatusCorrupt
+		res.Message = "无法读取时长 " + info.Warning
+		return
+	}
+
+	if res.Status == VerifyStatusUnknown {
+		return
+	}
+	res.Status = VerifyStatusOk
+	if res.ExpectedDuration > 0 {
+		expected := float64(res.ExpectedDuration)
+		if res.Tempo > 0 {
+			expected /= res.Tempo
+		}
+		// 允许2%及2秒的误差
+		if info.Duration < expected*0.98-2 {
+			res.Status = VerifyStatusTruncated
+			res.Message = fmt.Sprintf("时长 %s，应为 %s", utils.FormatSeconds(int(info.Duration)), utils.FormatSeconds(int(expected)))
+			return
+		}
+	}
+	if res.ExpectedSize > 0 && res.Tempo == 0 {
+		ratio := float64(info.Size) / float64(res.ExpectedSize)
+		if res.ExpectedDuration == 0 && ratio < 0.9 {
+			res.Status = VerifyStatusTruncated
+			res.Message = fmt.Sprintf("大小 %d，应为 %d", info.Size, res.ExpectedSize)
+		} else if ratio < 0.9 || ratio > 1.1 {
+			// 封面、响度标准化等处理都会改变文件大小，时长正常时只做提示
+			res.Message = fmt.Sprintf("大小 %d 与接口 %d 不一致", info.Size, res.ExpectedSize)
+		}
+	}
+	if info.Warning != "" && res.Message == "" {
+		res.Message = info.Warning
+	}
+}
+
+// fillExpected 根据文件位置匹配接口数据：课程目录按清单匹配节目，其他按文件名中的 bookId 匹配
+// 文件名带倍速后缀且去掉后缀后与下载的文件名一致时，才按倍速副本校验
+func (v *verifier) fillExpected(res *VerifyResult, path, base string) {
+	res.Status = VerifyStatusUnknown
+	dir := filepath.Dir(path)
+	rel, _ := filepath.Rel(v.root, dir)
+	if strings.HasPrefix(rel, utils.FileName(getSubDir(4), "")+string(filepath.Separator)) {
+		res.Kind = "course"
+		manifest, programs := v.coursePrograms(dir)
+		res.ID = manifest.CourseId
+		program, ok := programs[base]
+		if !ok {
+			if name, tempo, isTempo := splitTempoName(base); isTempo {
+				if program, ok = programs[name]; ok {
+					res.Tempo = tempo
+				}
+			}
+		}
+		if ok {
+			res.Status = ""
+			res.ExpectedDuration = program.Duration
+			if res.DownloadType == 1 {
+				res.ExpectedSize = program.MediaFilesize
+			}
+		} else if manifest.CourseId == 0 {
+			res.Message = "课程目录缺少 " + courseManifestName
+		} else {
+			res.Message = "未匹配到节目"
+		}
+		return
+	}
+
+	m := bookIDRegexp.FindStringSubmatch(base)
+	if m == nil {
+		res.Message = "无法从文件名识别 bookId"
+		return
+	}
+	res.Kind = "book"
+	res.ID, _ = strconv.Atoi(m[1])
+	detail := v.book(res.ID)
+	if detail == nil {
+		res.Message = "获取书籍详情失败"
+		return
+	}
+	res.Status = ""
+	res.BusinessType = detail.BookInfo.BusinessType
+	bookName := utils.FileName(utils.Int2String(res.ID)+"."+strings.TrimSpace(detail.BookInfo.Title), "")
+	if name, tempo, isTempo := splitTempoName(base); isTempo && base != bookName && name == bookName {
+		res.Tempo = tempo
+	}
+	if out, ok := outputRel(dir); ok && out != utils.FileName(getSubDir(res.BusinessType), "") {
+		res.SubDir = out
+	}
+	media := detail.AudioInfo
+	if res.DownloadType == 2 {
+		media = detail.VideoInfo
+	}
+	res.ExpectedDuration = media.Duration
+	res.ExpectedSize = media.MediaFilesize
+}
+
+// outputRel 目录相对于输出目录的路径，不在输出目录下时 ok 为 false
+func outputRel(dir string) (rel string, ok bool) {
+	root, err := filepath.Abs(OutputDir)
+	if err != nil {
+		return
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return
+	}
+	if rel, err = filepath.Rel(root, dir); err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+func (v *verifier) book(bookID int) *services.BookContent {
+	if detail, ok := v.books[bookID]; ok {
+		return detail
+	}
+	detail, err := Instance.BookContent(bookID)
+	if err != nil {
+		fmt.Println(err)
+		v.books[bookID] = nil
+		return nil
+	}
+	v.books[bookID] = &detail
+	return &detail
+}
+
+func (v *verifier) coursePrograms(dir string) (CourseManifest, map[string]services.Program) {
+	if programs, ok := v.programs[dir]; ok {
+		return v.manifest[dir], programs
+	}
+	programs := make(map[string]services.Program)
+	v.programs[dir] = programs
+
+	manifest, err := readCourseManifest(dir)
+	if err != nil {
+		return manifest, programs
+	}
+	v.manifest[dir] = manifest
+	list, err := Instance.ProgramList(services.ProgramListParam{
+		Page:     services.ProgramPage{PageNo: 1, PageSize: 1000},
+		CourseId: manifest.CourseId,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return manifest, programs
+	}
+	for _, program := range list {
+		programs[utils.FileName(courseItemName(program), "")] = program
+	}
+	return manifest, programs
+}
+
+// Redownload 删除校验失败的文件并重新下载
+func Redownload(results []VerifyResult, opt DownloadOptions) {
+	type courseTask struct {
+		id, downloadType int
+		tempo            float64
+	}
+	var courses []courseTask
+	queued := make(map[courseTask]bool)
+
+	for _, res := range results {
+		if (res.Status != VerifyStatusTruncated && res.Status != VerifyStatusCorrupt) || res.ID == 0 {
+			continue
+		}
+		if err := os.Remove(res.File); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("【\033[33;1m%s\033[0m】已删除，等待重新下载\n", res.File)
+
+		o := opt
+		o.Tempo = res.Tempo
+		o.SubDir = res.SubDir
+		switch res.Kind {
+		case "book":
+			Download(res.ID, res.BusinessType, res.DownloadType, o) // nolint
+		case "course":
+			task := courseTask{id: res.ID, downloadType: res.DownloadType, tempo: res.Tempo}
+			if !queued[task] {
+				queued[task] = true
+				courses = append(courses, task)
+			}
+		}
+	}
+
+	// 课程下载会跳过已存在的文件，每门课程只需重新下载一次
+	for _, task := range courses {
+		o := opt
+		o.Tempo = task.tempo
+		DownloadCourse(task.id, task.downloadType, o) // nolint
+	}
+}
+
+// verifyTask 后台校验任务的状态和最近一次的校验报告
+var verifyTask struct {
+	running bool
+	report  *VerifyReport
+	mutex   sync.Mutex
+}
+
+// runVerify 后台校验输出目录，通过通知报告进度，完成后按需重新下载
+func runVerify(redownload bool, opt DownloadOptions) {
+	const id, title = "verify", "校验下载文件"
+	defer func() {
+		verifyTask.mutex.Lock()
+		verifyTask.running = false
+		verifyTask.mutex.Unlock()
+	}()
+
+	SendDownloadStarted(id, "verify", title)
+	report, err := Verify(OutputDir, func(current, total int) {
+		SendDownloadProgress(id, "verify", title, current, total)
+	})
+	if err != nil {
+		SendDownloadFailed(id, "verify", title, err.Error())
+		return
+	}
+	verifyTask.mutex.Lock()
+	verifyTask.report = &report
+	verifyTask.mutex.Unlock()
+	SendDownloadCompleted(id, "verify", title)
+
+	if redownload {
+		Redownload(report.Results, opt)
+	}
+}
+
+// handleVerify 在后台校验输出目录，进度通过通知发送，结果通过 /api/verify/report 获取
+func handleVerify(c *gin.Context) {
+	var opt DownloadOptions
+	redownload, _ := strconv.ParseBool(c.Query("redownload"))
+	if redownload {
+		var err error
+		if opt, err = parseDownloadOptions(c); err != nil {
+			Error(c, err)
+			return
+		}
+	}
+
+	verifyTask.mutex.Lock()
+	defer verifyTask.mutex.Unlock()
+	if verifyTask.running {
+		Error(c, fmt.Errorf("正在校验，请等待完成"))
+		return
+	}
+	verifyTask.running = true
+	go runVerify(redownload, opt)
+	Success(c, gin.H{"dir": OutputDir, "redownload": redownload})
+}
+
+// handleGetVerifyReport 最近一次校验的报告
+func handleGetVerifyReport(c *gin.Context) {
+	verifyTask.mutex.Lock()
+	defer verifyTask.mutex.Unlock()
+	if verifyTask.report == nil {
+		if verifyTask.running {
+			Error(c, fmt.Errorf("正在校验，请等待完成"))
+		} else {
+			Error(c, fmt.Errorf("还没有校验记录"))
+		}
+		return
+	}
+	Success(c, gin.H{"running": verifyTask.running, "report": verifyTask.report})
+}
+
+// runVerifyCommand 命令行校验：fs verify [-dir output] [-redownload]
+func runVerifyCommand(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	dir := flags.String("dir", OutputDir, "需要校验的下载目录")
+	redownload := flags.Bool("redownload", false, "重新下载不完整或损坏的文件")
+	flags.Parse(args) // nolint
+
+	report, err := Verify(*dir, nil)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	for _, res := range report.Results {
+		color := "33"
+		if res.Status != VerifyStatusUnknown {
+			color = "31"
+		}
+		fmt.Printf("【\033[%s;1m%s\033[0m】%s %s\n", color, res.Status, res.File, res.Message)
+	}
+	fmt.Printf("共 %d 个文件：正常 %d，不完整 %d，损坏 %d，无法匹配 %d\n",
+		report.Total, report.Ok, report.Truncated, report.Corrupt, report.Unknown)
+
+	if *redownload {
+		Redownload(report.Results, defaultDownloadOptions())
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/yann0917/fs-gui/utils"
+)
+
+func TestSplitTempoName(t *testing.T) {
+	tests := []struct {
+		base      string
+		wantName  string
+		wantTempo float64
+		wantOk    bool
+	}{
+		{"1.标题_1.5x", "1.标题", 1.5, true},
+		{"1.标题_2x", "1.标题", 2, true},
+		{"1.标题_0.5x", "1.标题", 0.5, true},
+		{"1.标题_4x", "1.标题", 4, true},
+		{"1.标题", "1.标题", 0, false},
+		{"1.标题_1x", "1.标题_1x", 0, false},
+		{"1.标题_0.25x", "1.标题_0.25x", 0, false},
+		{"1.标题_10x", "1.标题_10x", 0, false},
+		{"1.标题_x", "1.标题_x", 0, false},
+		{"1.标题_1.5x版", "1.标题_1.5x版", 0, false},
+	}
+	for _, tt := range tests {
+		name, tempo, ok := splitTempoName(tt.base)
+		if name != tt.wantName || tempo != tt.wantTempo || ok != tt.wantOk {
+			t.Errorf("splitTempoName(%q) = %q, %v, %v, want %q, %v, %v",
+				tt.base, name, tempo, ok, tt.wantName, tt.wantTempo, tt.wantOk)
+		}
+	}
+}
+
+func TestCheckMedia(t *testing.T) {
+	tests := []struct {
+		name        string
+		res         VerifyResult
+		info        utils.MediaInfo
+		wantStatus  string
+		wantMessage bool
+	}{
+		{"时长一致", VerifyResult{ExpectedDuration: 600}, utils.MediaInfo{Duration: 600}, VerifyStatusOk, false},
+		{"时长在误差内", VerifyResult{ExpectedDuration: 600}, utils.MediaInfo{Duration: 587}, VerifyStatusOk, false},
+		{"时长不足", VerifyResult{ExpectedDuration: 600}, utils.MediaInfo{Duration: 585}, VerifyStatusTruncated, true},
+		{"倍速副本时长一致", VerifyResult{ExpectedDuration: 600, Tempo: 2}, utils.MediaInfo{Duration: 300}, VerifyStatusOk, false},
+		{"倍速副本时长在误差内", VerifyResult{ExpectedDuration: 600, Tempo: 2}, utils.MediaInfo{Duration: 293}, VerifyStatusOk, false},
+		{"倍速副本时长不足", VerifyResult{ExpectedDuration: 600, Tempo: 2}, utils.MediaInfo{Duration: 290}, VerifyStatusTruncated, true},
+		{"倍速副本不比较大小", VerifyResult{ExpectedSize: 1000, Tempo: 1.5}, utils.MediaInfo{Duration: 10, Size: 500}, VerifyStatusOk, false},
+		{"无时长时大小不足", VerifyResult{ExpectedSize: 1000}, utils.MediaInfo{Duration: 10, Size: 899}, VerifyStatusTruncated, true},
+		{"无时长时大小在误差内", VerifyResult{ExpectedSize: 1000}, utils.MediaInfo{Duration: 10, Size: 900}, VerifyStatusOk, false},
+		{"时长正常时大小偏小只提示", VerifyResult{ExpectedDuration: 600, ExpectedSize: 1000}, utils.MediaInfo{Duration: 600, Size: 800}, VerifyStatusOk, true},
+		{"时长正常时大小偏大只提示", VerifyResult{ExpectedDuration: 600, ExpectedSize: 1000}, utils.MediaInfo{Duration: 600, Size: 1101}, VerifyStatusOk, true},
+		{"大小在误差内", VerifyResult{ExpectedDuration: 600, ExpectedSize: 1000}, utils.MediaInfo{Duration: 600, Size: 1100}, VerifyStatusOk, false},
+		{"无法读取时长", VerifyResult{ExpectedDuration: 600}, utils.MediaInfo{Size: 1000}, VerifyStatusCorrupt, true},
+		{"未匹配接口数据", VerifyResult{Status: VerifyStatusUnknown}, utils.MediaInfo{Duration: 10}, VerifyStatusUnknown, false},
+		{"未匹配接口数据且无法读取时长", VerifyResult{Status: VerifyStatusUnknown}, utils.MediaInfo{}, VerifyStatusCorrupt, true},
+		{"ffprobe 警告", VerifyResult{ExpectedDuration: 600}, utils.MediaInfo{Duration: 600, Warning: "invalid frame"}, VerifyStatusOk, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := tt.res
+			checkMedia(&res, tt.info)
+			if res.Status != tt.wantStatus || (res.Message != "") != tt.wantMessage {
+				t.Errorf("checkMedia() status = %q, message = %q, want %q, message %v",
+					res.Status, res.Message, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}